@@ -0,0 +1,124 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// ErrInconsistentRoots is an error that indicates that a root does not contain all the leafs of a previous root
+var ErrInconsistentRoots = errors.New("roots are not consistent")
+
+// ConsistencyProof proves that all the leafs of an old root are in a new root, with the
+// same values. It contains all the leafs of the old root, that alone are enough to compute
+// the old root, and the MultiProof of their positions in the new root
+type ConsistencyProof struct {
+	Leafs    []Leaf
+	NewProof *MultiProof
+}
+
+// GenerateConsistencyProof generates the ConsistencyProof between the oldRoot and the
+// newRoot, both stored in the MT database
+func (mt *MerkleTree) GenerateConsistencyProof(oldRoot, newRoot Hash) (*ConsistencyProof, error) {
+	return mt.GenerateConsistencyProofContext(context.Background(), oldRoot, newRoot)
+}
+
+// GenerateConsistencyProofContext generates the ConsistencyProof as GenerateConsistencyProof,
+// returning the error of the context if it is done before the proof has been generated
+func (mt *MerkleTree) GenerateConsistencyProofContext(ctx context.Context, oldRoot, newRoot Hash) (*ConsistencyProof, error) {
+	leafs, err := mt.leafs(ctx, oldRoot)
+	if err != nil {
+		return nil, err
+	}
+	var his []Hash
+	for _, leaf := range leafs {
+		his = append(his, mt.hasher.HashBytes(leaf.Index()))
+	}
+	mp, err := mt.generateMultiProof(ctx, newRoot, his)
+	if err != nil {
+		return nil, err
+	}
+	p := &ConsistencyProof{
+		Leafs:    leafs,
+		NewProof: mp,
+	}
+	if !CheckConsistencyProofWithHasher(mt.hasher, oldRoot, newRoot, p, mt.numLevels) {
+		return nil, ErrInconsistentRoots
+	}
+	return p, nil
+}
+
+// CheckConsistencyProof validates that the ConsistencyProof proves that every leaf of
+// the oldRoot is in the newRoot without changes
+func CheckConsistencyProof(oldRoot, newRoot Hash, p *ConsistencyProof, numLevels int) bool {
+	return CheckConsistencyProofWithHasher(Keccak256Hasher, oldRoot, newRoot, p, numLevels)
+}
+
+// CheckConsistencyProofWithHasher validates the ConsistencyProof between the roots of a tree with the Hasher
+func CheckConsistencyProofWithHasher(h Hasher, oldRoot, newRoot Hash, p *ConsistencyProof, numLevels int) bool {
+	if len(p.Leafs) == 0 {
+		// an empty tree is consistent with any tree
+		return bytes.Equal(oldRoot[:], EmptyNodeValue[:])
+	}
+	var his, hts []Hash
+	for _, leaf := range p.Leafs {
+		if int(leaf.indexLength) > len(leaf.data) {
+			return false
+		}
+		his = append(his, h.HashBytes(leaf.Index()))
+		hts = append(hts, h.HashBytes(leaf.Bytes()))
+	}
+	// the leafs are all the leafs of the oldRoot if it can be computed without any sibling
+	computedOldRoot, ok := multiProofRoot(h, nil, his, hts, numLevels)
+	if !ok || !bytes.Equal(computedOldRoot[:], oldRoot[:]) {
+		return false
+	}
+	return p.NewProof != nil && CheckMultiProofWithHasher(h, newRoot, p.NewProof, his, hts, numLevels)
+}
+
+// Bytes returns the ConsistencyProof encoded in a byte array
+func (p *ConsistencyProof) Bytes() []byte {
+	var b []byte
+	b = append(b, Uint32ToBytes(uint32(len(p.Leafs)))...)
+	for _, leaf := range p.Leafs {
+		b = append(b, Uint32ToBytes(leaf.indexLength)...)
+		b = append(b, Uint32ToBytes(uint32(len(leaf.data)))...)
+		b = append(b, leaf.data...)
+	}
+	newProof := p.NewProof
+	if newProof == nil {
+		// without the proof of the new root, an empty MultiProof is encoded
+		newProof = &MultiProof{}
+	}
+	return append(b, newProof.Bytes()...)
+}
+
+// ParseConsistencyProof returns the ConsistencyProof encoded in the byte array
+func ParseConsistencyProof(b []byte) (*ConsistencyProof, error) {
+	if len(b) < 4 {
+		return nil, ErrInvalidProof
+	}
+	n := BytesToUint32(b[:4])
+	b = b[4:]
+	p := &ConsistencyProof{}
+	for i := uint32(0); i < n; i++ {
+		if len(b) < 8 {
+			return nil, ErrInvalidProof
+		}
+		indexLength := BytesToUint32(b[:4])
+		dataLength := uint64(BytesToUint32(b[4:8]))
+		if uint64(len(b)-8) < dataLength {
+			return nil, ErrInvalidProof
+		}
+		data := make([]byte, dataLength)
+		copy(data, b[8:])
+		p.Leafs = append(p.Leafs, Leaf{data: data, indexLength: indexLength})
+		b = b[8+dataLength:]
+	}
+	mp, err := ParseMultiProof(b)
+	if err != nil {
+		return nil, err
+	}
+	p.NewProof = mp
+	return p, nil
+}
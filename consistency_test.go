@@ -0,0 +1,82 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsistencyProof(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	emptyRoot := mt.Root()
+	for i := 0; i < 30; i++ {
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	oldRoot := mt.Root()
+	for i := 30; i < 60; i++ {
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	newRoot := mt.Root()
+
+	p, err := mt.GenerateConsistencyProof(oldRoot, newRoot)
+	assert.Nil(t, err)
+	assert.Equal(t, 30, len(p.Leafs))
+	assert.True(t, CheckConsistencyProof(oldRoot, newRoot, p, mt.NumLevels()))
+
+	parsed, err := ParseConsistencyProof(p.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, p, parsed)
+	assert.True(t, CheckConsistencyProof(oldRoot, newRoot, parsed, mt.NumLevels()))
+
+	// a root is consistent with itself and with the empty root
+	p, err = mt.GenerateConsistencyProof(newRoot, newRoot)
+	assert.Nil(t, err)
+	assert.True(t, CheckConsistencyProof(newRoot, newRoot, p, mt.NumLevels()))
+	p, err = mt.GenerateConsistencyProof(emptyRoot, newRoot)
+	assert.Nil(t, err)
+	assert.True(t, CheckConsistencyProof(emptyRoot, newRoot, p, mt.NumLevels()))
+
+	// the old root has leafs that are not in the new one
+	_, err = mt.GenerateConsistencyProof(newRoot, oldRoot)
+	assert.Equal(t, ErrInconsistentRoots, err)
+}
+
+func TestConsistencyProofTampered(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	oldRoot := mt.Root()
+	assert.Nil(t, mt.Add(newTestBytesLeaf("this is a test leaf", 15)))
+	newRoot := mt.Root()
+
+	p, err := mt.GenerateConsistencyProof(oldRoot, newRoot)
+	assert.Nil(t, err)
+
+	// without one of the leafs of the old root
+	leafs := p.Leafs
+	p.Leafs = leafs[1:]
+	assert.False(t, CheckConsistencyProof(oldRoot, newRoot, p, mt.NumLevels()))
+	// with a modified leaf
+	p.Leafs = append([]Leaf{NewLeaf(leafs[0].Index(), []byte("modified"))}, leafs[1:]...)
+	assert.False(t, CheckConsistencyProof(oldRoot, newRoot, p, mt.NumLevels()))
+	// with a leaf that is only in the new root
+	p.Leafs = append(leafs, NewLeaf([]byte("this is a test "), []byte("leaf")))
+	assert.False(t, CheckConsistencyProof(oldRoot, newRoot, p, mt.NumLevels()))
+
+	_, err = ParseConsistencyProof([]byte{1, 0, 0, 0, 1, 0, 0, 0, 9, 0, 0, 0})
+	assert.Equal(t, ErrInvalidProof, err)
+
+	// without the proof of the new root, an empty MultiProof is encoded
+	p = &ConsistencyProof{Leafs: leafs}
+	parsed, err := ParseConsistencyProof(p.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, leafs, parsed.Leafs)
+	assert.Equal(t, 0, len(parsed.NewProof.NonEmpty))
+	assert.False(t, CheckConsistencyProof(oldRoot, newRoot, parsed, mt.NumLevels()))
+}
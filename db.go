@@ -2,39 +2,149 @@ package merkletree
 
 import (
 	"bytes"
+	"context"
+	"errors"
 
 	"github.com/fatih/color"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
-func (mt *MerkleTree) Insert(key Hash, nodeType byte, indexLength uint32, nodeBytes []byte) error {
+// errInvalidNodeEncoding is returned when a stored node is too short to contain the node type and the index length
+var errInvalidNodeEncoding = errors.New("invalid node encoding")
+
+// encodeNode returns the value stored in the database for a node, with the node type
+// and the index length before the node bytes
+func encodeNode(nodeType byte, indexLength uint32, nodeBytes []byte) []byte {
 	// add nodetype at the first byte of the value
 	var value []byte
 	value = append(value, nodeType)
 	indexLengthBytes := Uint32ToBytes(indexLength)
 	value = append(value, indexLengthBytes[:]...)
 	value = append(value, nodeBytes[:]...)
+	return value
+}
 
-	err := mt.storage.Put(key[:], value, nil)
+// decodeNode returns the node type, the index length and the node bytes of a value stored in the database
+func decodeNode(value []byte) (byte, uint32, []byte, error) {
+	if len(value) < 5 {
+		return 0, 0, EmptyNodeValue[:], errInvalidNodeEncoding
+	}
+	// get nodetype of the first byte of the value
+	nodeType := value[0]
+	indexLength := BytesToUint32(value[1:5])
+	nodeBytes := value[5:]
+	return nodeType, indexLength, nodeBytes, nil
+}
+
+// nodeKey returns the database key of a node, prefixed by the namespace unless the nodes are shared
+func (mt *MerkleTree) nodeKey(key Hash) []byte {
+	return append(mt.nodePrefix[:len(mt.nodePrefix):len(mt.nodePrefix)], key[:]...)
+}
+
+// sharedNodes returns if the nodes are stored without the prefix of the namespace
+func (mt *MerkleTree) sharedNodes() bool {
+	return len(mt.nodePrefix) == 0
+}
+
+// treeKey returns the database key of a record of the tree, as the root or the metadata,
+// prefixed by the namespace
+func (mt *MerkleTree) treeKey(key Hash) []byte {
+	return append(mt.prefix[:len(mt.prefix):len(mt.prefix)], key[:]...)
+}
+
+func (mt *MerkleTree) put(key []byte, nodeType byte, indexLength uint32, nodeBytes []byte) error {
+	value := encodeNode(nodeType, indexLength, nodeBytes)
+	if mt.staged != nil {
+		mt.staged[string(key)] = value
+		return nil
+	}
+	err := mt.storage.Put(key, value, nil)
 	if err != nil {
 		color.Red(err.Error())
-		return err
+		return storageError(err)
+	}
+	return nil
+}
+
+// stagedWrites keeps in memory the writes of a batch of a staged MT
+type stagedWrites map[string][]byte
+
+func (s stagedWrites) Put(key, value []byte) {
+	s[string(key)] = append([]byte{}, value...)
+}
+
+func (s stagedWrites) Delete(key []byte) {
+	delete(s, string(key))
+}
+
+// writeBatch writes the batch to the database in a single write, or keeps its writes in
+// memory if the writes are staged
+func (mt *MerkleTree) writeBatch(batch *leveldb.Batch) error {
+	if mt.staged != nil {
+		return batch.Replay(stagedWrites(mt.staged))
+	}
+	if err := mt.storage.Write(batch, nil); err != nil {
+		color.Red(err.Error())
+		return storageError(err)
 	}
 	return nil
 }
 
+func (mt *MerkleTree) get(key []byte) (byte, uint32, []byte, error) {
+	if value, ok := mt.staged[string(key)]; ok {
+		return decodeNode(value)
+	}
+	value, err := mt.storage.Get(key, nil)
+	if err != nil {
+		return 0, 0, EmptyNodeValue[:], storageError(err)
+	}
+	return decodeNode(value)
+}
+
+func (mt *MerkleTree) Insert(key Hash, nodeType byte, indexLength uint32, nodeBytes []byte) error {
+	return mt.put(mt.nodeKey(key), nodeType, indexLength, nodeBytes)
+}
+
 func (mt *MerkleTree) Get(key Hash) (byte, uint32, []byte, error) {
 	if bytes.Equal(key[:], EmptyNodeValue[:]) {
 		return 0, 0, EmptyNodeValue[:], nil
 	}
+	nodeType, indexLength, nodeBytes, err := mt.get(mt.nodeKey(key))
+	return nodeType, indexLength, nodeBytes, nodeError(key, err)
+}
 
-	value, err := mt.storage.Get(key[:], nil)
-	if err != nil {
-		return 0, 0, EmptyNodeValue[:], err
+// stage returns a copy of the MT whose writes are kept in memory, over the ones of the
+// database, until they are written by writeStaged
+func (mt *MerkleTree) stage() *MerkleTree {
+	staged := *mt
+	staged.staged = make(map[string][]byte)
+	metadata := *mt.metadata
+	staged.metadata = &metadata
+	staged.stats = mt.TrackedStats()
+	return &staged
+}
+
+// writeStaged writes to the database the writes kept in memory by the staged MT in a
+// single batch, and makes its root, metadata and stats the ones of the MT
+func (mt *MerkleTree) writeStaged(staged *MerkleTree) error {
+	batch := new(leveldb.Batch)
+	for key, value := range staged.staged {
+		batch.Put([]byte(key), value)
 	}
+	if err := mt.storage.Write(batch, nil); err != nil {
+		return storageError(err)
+	}
+	mt.root = staged.root
+	mt.metadata = staged.metadata
+	mt.stats = staged.stats
+	return nil
+}
 
-	// get nodetype of the first byte of the value
-	nodeType := value[0]
-	indexLength := BytesToUint32(value[1:5])
-	nodeBytes := value[5:]
-	return nodeType, indexLength, nodeBytes, err
+// getContext returns the node stored under the key as Get, or the error of the context
+// if it is done, so the traversals can be cancelled between storage reads
+func (mt *MerkleTree) getContext(ctx context.Context, key Hash) (byte, uint32, []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, EmptyNodeValue[:], err
+	}
+	return mt.Get(key)
 }
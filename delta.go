@@ -0,0 +1,98 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+)
+
+// ProofDelta contains the siblings of the Merkle Proof of a position that change
+// between two roots, used to update a proof without generating it again
+type ProofDelta struct {
+	Changed  [32]byte // bitmap of the levels with a different sibling
+	Siblings []Hash   // new siblings of the changed levels, from the root, can be empty
+}
+
+// GenerateProofDelta generates the ProofDelta of the position of the Hash of the Index
+// (Hi) between the oldRoot and the newRoot, both stored in the MT database
+func (mt *MerkleTree) GenerateProofDelta(hi Hash, oldRoot, newRoot Hash) (*ProofDelta, error) {
+	return mt.GenerateProofDeltaContext(context.Background(), hi, oldRoot, newRoot)
+}
+
+// GenerateProofDeltaContext generates the ProofDelta as GenerateProofDelta, returning the
+// error of the context if it is done before the proofs have been generated
+func (mt *MerkleTree) GenerateProofDeltaContext(ctx context.Context, hi Hash, oldRoot, newRoot Hash) (*ProofDelta, error) {
+	oldProof, err := mt.generateProof(ctx, oldRoot, hi)
+	if err != nil {
+		return nil, err
+	}
+	newProof, err := mt.generateProof(ctx, newRoot, hi)
+	if err != nil {
+		return nil, err
+	}
+	oldSiblings, err := ExpandProof(oldProof, mt.numLevels)
+	if err != nil {
+		return nil, err
+	}
+	newSiblings, err := ExpandProof(newProof, mt.numLevels)
+	if err != nil {
+		return nil, err
+	}
+	d := &ProofDelta{}
+	for level := range newSiblings {
+		if !bytes.Equal(oldSiblings[level][:], newSiblings[level][:]) {
+			setbitmap(d.Changed[:], uint(level))
+			d.Siblings = append(d.Siblings, newSiblings[level])
+		}
+	}
+	return d, nil
+}
+
+// UpdateProof applies the ProofDelta to the Merkle Proof of the old root, returning the
+// Merkle Proof of the same position for the new root
+func UpdateProof(proof []byte, d *ProofDelta, numLevels int) ([]byte, error) {
+	siblings, err := ExpandProof(proof, numLevels)
+	if err != nil {
+		return nil, err
+	}
+	pos := 0
+	for level := 0; level < len(d.Changed)*8; level++ {
+		if !testbitmap(d.Changed[:], uint(level)) {
+			continue
+		}
+		if level >= len(siblings) || pos >= len(d.Siblings) {
+			return nil, ErrInvalidProof
+		}
+		siblings[level] = d.Siblings[pos]
+		pos++
+	}
+	if pos != len(d.Siblings) {
+		return nil, ErrInvalidProof
+	}
+	return compressProof(siblings), nil
+}
+
+// Bytes returns the ProofDelta encoded in a byte array
+func (d *ProofDelta) Bytes() []byte {
+	var b []byte
+	b = append(b, d.Changed[:]...)
+	for _, sibling := range d.Siblings {
+		b = append(b, sibling[:]...)
+	}
+	return b
+}
+
+// ParseProofDelta returns the ProofDelta encoded in the byte array
+func ParseProofDelta(b []byte) (*ProofDelta, error) {
+	d := &ProofDelta{}
+	hashLen := len(EmptyNodeValue)
+	if len(b) < len(d.Changed) || (len(b)-len(d.Changed))%hashLen != 0 {
+		return nil, ErrInvalidProof
+	}
+	copy(d.Changed[:], b[:len(d.Changed)])
+	for i := len(d.Changed); i < len(b); i += hashLen {
+		var sibling Hash
+		copy(sibling[:], b[i:i+hashLen])
+		d.Siblings = append(d.Siblings, sibling)
+	}
+	return d, nil
+}
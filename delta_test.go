@@ -0,0 +1,66 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProofDelta(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	leaf := newTestBytesLeaf("this is a test leaf", 15)
+	assert.Nil(t, mt.Add(leaf))
+	absent := newTestBytesLeaf("this is not in the tree", 15)
+
+	proof, err := mt.GenerateProof(leaf.Hi())
+	assert.Nil(t, err)
+	absentProof, err := mt.GenerateProof(absent.Hi())
+	assert.Nil(t, err)
+	for i := 0; i < 30; i++ {
+		oldRoot := mt.Root()
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+
+		d, err := mt.GenerateProofDelta(leaf.Hi(), oldRoot, mt.Root())
+		assert.Nil(t, err)
+		// adding a leaf changes at most one sibling of the other positions
+		assert.True(t, len(d.Siblings) <= 1)
+		parsed, err := ParseProofDelta(d.Bytes())
+		assert.Nil(t, err)
+		assert.Equal(t, d, parsed)
+		proof, err = UpdateProof(proof, parsed, mt.NumLevels())
+		assert.Nil(t, err)
+		assert.True(t, CheckProof(mt.Root(), proof, leaf.Hi(), HashBytes(leaf.Bytes()), mt.NumLevels()))
+
+		d, err = mt.GenerateProofDelta(absent.Hi(), oldRoot, mt.Root())
+		assert.Nil(t, err)
+		absentProof, err = UpdateProof(absentProof, d, mt.NumLevels())
+		assert.Nil(t, err)
+		assert.True(t, CheckProof(mt.Root(), absentProof, absent.Hi(), EmptyNodeValue, mt.NumLevels()))
+	}
+	// the updated proof is the same than a new one
+	newProof, err := mt.GenerateProof(leaf.Hi())
+	assert.Nil(t, err)
+	assert.Equal(t, newProof, proof)
+}
+
+func TestUpdateProofErrors(t *testing.T) {
+	d := &ProofDelta{}
+	_, err := UpdateProof([]byte{1, 2, 3}, d, 140)
+	assert.Equal(t, ErrInvalidProof, err)
+
+	proof := make([]byte, 32)
+	setbitmap(d.Changed[:], 200)
+	d.Siblings = []Hash{HashBytes([]byte("sibling"))}
+	_, err = UpdateProof(proof, d, 140)
+	assert.Equal(t, ErrInvalidProof, err)
+
+	d = &ProofDelta{Siblings: []Hash{HashBytes([]byte("sibling"))}}
+	_, err = UpdateProof(proof, d, 140)
+	assert.Equal(t, ErrInvalidProof, err)
+
+	_, err = ParseProofDelta(make([]byte, 40))
+	assert.Equal(t, ErrInvalidProof, err)
+}
@@ -0,0 +1,89 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+)
+
+// rootsDiff contains the leafs that have changed between two roots
+type rootsDiff struct {
+	added    []Value
+	removed  []Value
+	modified []Value
+}
+
+// diffLeafs compares the leafs of two subtrees in the same position, matching them by index
+func (d *rootsDiff) diffLeafs(oldLeafs, newLeafs []Leaf) {
+	newByIndex := make(map[string]Leaf)
+	for _, leaf := range newLeafs {
+		newByIndex[string(leaf.Index())] = leaf
+	}
+	matched := make(map[string]bool)
+	for _, oldLeaf := range oldLeafs {
+		index := string(oldLeaf.Index())
+		newLeaf, ok := newByIndex[index]
+		if !ok {
+			d.removed = append(d.removed, oldLeaf)
+			continue
+		}
+		matched[index] = true
+		if !bytes.Equal(oldLeaf.Bytes(), newLeaf.Bytes()) {
+			d.modified = append(d.modified, newLeaf)
+		}
+	}
+	for _, newLeaf := range newLeafs {
+		if !matched[string(newLeaf.Index())] {
+			d.added = append(d.added, newLeaf)
+		}
+	}
+}
+
+func (mt *MerkleTree) diffLevel(ctx context.Context, oldHash, newHash Hash, d *rootsDiff) error {
+	if bytes.Equal(oldHash[:], newHash[:]) {
+		return nil
+	}
+	oldType, _, oldBytes, err := mt.getContext(ctx, oldHash)
+	if err != nil {
+		return err
+	}
+	newType, _, newBytes, err := mt.getContext(ctx, newHash)
+	if err != nil {
+		return err
+	}
+	if oldType == byte(normalNodeType) && newType == byte(normalNodeType) {
+		oldNode := parseNodeBytes(oldBytes)
+		newNode := parseNodeBytes(newBytes)
+		if err := mt.diffLevel(ctx, oldNode.ChildL, newNode.ChildL, d); err != nil {
+			return err
+		}
+		return mt.diffLevel(ctx, oldNode.ChildR, newNode.ChildR, d)
+	}
+	// one of the subtrees is a leaf or is empty, so the leafs of both have to be compared
+	oldLeafs, err := mt.leafs(ctx, oldHash)
+	if err != nil {
+		return err
+	}
+	newLeafs, err := mt.leafs(ctx, newHash)
+	if err != nil {
+		return err
+	}
+	d.diffLeafs(oldLeafs, newLeafs)
+	return nil
+}
+
+// Diff walks the trees of the oldRoot and the newRoot, both stored in the MT database,
+// skipping the identical subtrees, and returns the leafs that have been added, removed
+// and modified in the newRoot. The modified leafs are returned with their new value
+func (mt *MerkleTree) Diff(oldRoot, newRoot Hash) (added, removed, modified []Value, err error) {
+	return mt.DiffContext(context.Background(), oldRoot, newRoot)
+}
+
+// DiffContext returns the leafs that have changed between the roots as Diff, returning
+// the error of the context if it is done before the traversal has finished
+func (mt *MerkleTree) DiffContext(ctx context.Context, oldRoot, newRoot Hash) (added, removed, modified []Value, err error) {
+	var d rootsDiff
+	if err := mt.diffLevel(ctx, oldRoot, newRoot, &d); err != nil {
+		return nil, nil, nil, err
+	}
+	return d.added, d.removed, d.modified, nil
+}
@@ -0,0 +1,58 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	for i := 0; i < 20; i++ {
+		assert.Nil(t, mt.Put([]byte("index"+strconv.Itoa(i)), []byte("data"+strconv.Itoa(i))))
+	}
+	oldRoot := mt.Root()
+
+	added, removed, modified, err := mt.Diff(oldRoot, oldRoot)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(added)+len(removed)+len(modified))
+
+	for i := 20; i < 25; i++ {
+		assert.Nil(t, mt.Put([]byte("index"+strconv.Itoa(i)), []byte("data"+strconv.Itoa(i))))
+	}
+	added, removed, modified, err = mt.Diff(oldRoot, mt.Root())
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(added))
+	assert.Equal(t, 0, len(removed))
+	assert.Equal(t, 0, len(modified))
+
+	added, removed, _, err = mt.Diff(mt.Root(), oldRoot)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(added))
+	assert.Equal(t, 5, len(removed))
+
+	// a tree in its own namespace of the same database, sharing the nodes, without the
+	// leaf 0 and with the leaf 1 modified
+	mt2, err := NewWithOptions(mt.storage, 140, Options{Namespace: "modified", SharedNodes: true})
+	assert.Nil(t, err)
+	assert.Nil(t, mt2.Put([]byte("index1"), []byte("modified")))
+	for i := 2; i < 21; i++ {
+		assert.Nil(t, mt2.Put([]byte("index"+strconv.Itoa(i)), []byte("data"+strconv.Itoa(i))))
+	}
+	added, removed, modified, err = mt.Diff(oldRoot, mt2.Root())
+	assert.Nil(t, err)
+	assert.Equal(t, []Value{NewLeaf([]byte("index20"), []byte("data20"))}, added)
+	assert.Equal(t, []Value{NewLeaf([]byte("index0"), []byte("data0"))}, removed)
+	assert.Equal(t, []Value{NewLeaf([]byte("index1"), []byte("modified"))}, modified)
+
+	// from and to the empty tree
+	added, _, _, err = mt.Diff(EmptyNodeValue, oldRoot)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, len(added))
+	_, removed, _, err = mt.Diff(oldRoot, EmptyNodeValue)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, len(removed))
+}
@@ -0,0 +1,125 @@
+package merkletree
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// NodeNotFoundError is an error that indicates that a node of the tree is not in the database
+type NodeNotFoundError struct {
+	Hash Hash // hash of the missing node
+}
+
+func (e *NodeNotFoundError) Error() string {
+	return fmt.Sprintf("node %s not found", e.Hash.Hex())
+}
+
+// Unwrap returns the leveldb.ErrNotFound of the database
+func (e *NodeNotFoundError) Unwrap() error {
+	return leveldb.ErrNotFound
+}
+
+// CorruptNodeError is an error that indicates that a node does not match its hash or its
+// position in the tree, or can not be decoded
+type CorruptNodeError struct {
+	Hash   Hash   // hash of the corrupt node
+	Reason string // description of the corruption
+}
+
+func (e *CorruptNodeError) Error() string {
+	return fmt.Sprintf("corrupt node %s: %s", e.Hash.Hex(), e.Reason)
+}
+
+// DuplicateIndexError is an error that indicates that a leaf with the same index is already
+// in the tree. It matches ErrNodeAlreadyExists with errors.Is
+type DuplicateIndexError struct {
+	Hi Hash // hash of the index of the leaf
+}
+
+func (e *DuplicateIndexError) Error() string {
+	return fmt.Sprintf("%s: index %s", ErrNodeAlreadyExists, e.Hi.Hex())
+}
+
+// Is reports if the target is ErrNodeAlreadyExists
+func (e *DuplicateIndexError) Is(target error) bool {
+	return target == ErrNodeAlreadyExists
+}
+
+// PathCollisionError is an error that indicates that the leaf has the same path than a leaf
+// with a different index, as the path only uses numLevels-1 bits of the Hi. It matches
+// ErrPathCollision with errors.Is
+type PathCollisionError struct {
+	Hi     Hash // hash of the index of the leaf
+	LeafHi Hash // hash of the index of the leaf already in the path
+}
+
+func (e *PathCollisionError) Error() string {
+	return fmt.Sprintf("%s: index %s, leaf index %s", ErrPathCollision, e.Hi.Hex(), e.LeafHi.Hex())
+}
+
+// Is reports if the target is ErrPathCollision
+func (e *PathCollisionError) Is(target error) bool {
+	return target == ErrPathCollision
+}
+
+// leafCollision returns the error of adding the leaf of the Hi in the path of the leaf of
+// the leafHi, that is a DuplicateIndexError if both are the same index
+func leafCollision(hi, leafHi Hash) error {
+	if hi == leafHi {
+		return &DuplicateIndexError{hi}
+	}
+	return &PathCollisionError{hi, leafHi}
+}
+
+// NumLevelsMismatchError is an error that indicates that the stored tree has a different
+// number of levels than the requested one. It matches ErrNumLevelsMismatch with errors.Is
+type NumLevelsMismatchError struct {
+	NumLevels       int // number of levels requested
+	StoredNumLevels int // number of levels of the stored tree
+}
+
+func (e *NumLevelsMismatchError) Error() string {
+	return fmt.Sprintf("%s: %d, stored %d", ErrNumLevelsMismatch, e.NumLevels, e.StoredNumLevels)
+}
+
+// Is reports if the target is ErrNumLevelsMismatch
+func (e *NumLevelsMismatchError) Is(target error) bool {
+	return target == ErrNumLevelsMismatch
+}
+
+// StorageError is an error of the database, that is not related to the content of the tree
+type StorageError struct {
+	Err error // error returned by the database
+}
+
+func (e *StorageError) Error() string {
+	return "storage: " + e.Err.Error()
+}
+
+// Unwrap returns the error of the database
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
+// storageError wraps the errors of the database in a StorageError, except nil and
+// leveldb.ErrNotFound, that are handled by the callers
+func storageError(err error) error {
+	if err == nil || err == leveldb.ErrNotFound {
+		return err
+	}
+	return &StorageError{err}
+}
+
+// nodeError returns the typed error of a failed read of the node
+func nodeError(key Hash, err error) error {
+	switch err {
+	case nil:
+		return nil
+	case leveldb.ErrNotFound:
+		return &NodeNotFoundError{key}
+	case errInvalidNodeEncoding:
+		return &CorruptNodeError{key, err.Error()}
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func TestNodeNotFoundError(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	missing := HashBytes([]byte("missing node"))
+	_, _, _, err := mt.Get(missing)
+	var notFound *NodeNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, missing, notFound.Hash)
+	assert.True(t, errors.Is(err, leveldb.ErrNotFound))
+
+	// a tree whose nodes are missing
+	mt2 := newTestingMerkle(t, 140)
+	defer mt2.storage.Close()
+	assert.Nil(t, mt2.Add(newTestBytesLeaf("this is a test leaf", 15)))
+	_, err = mt.generateProof(context.Background(), mt2.Root(), HashBytes([]byte("this is a test ")))
+	assert.Equal(t, &NodeNotFoundError{mt2.Root()}, err)
+}
+
+func TestCorruptNodeError(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	assert.Nil(t, mt.storage.Put(mt.nodeKey(mt.Root()), []byte{1, 0}, nil))
+	_, err := mt.GetValueInPos(HashBytes([]byte("0 this is a tes")))
+	var corrupt *CorruptNodeError
+	assert.True(t, errors.As(err, &corrupt))
+	assert.Equal(t, mt.Root(), corrupt.Hash)
+
+	// the nodes fetched by Sync are validated
+	mt1 := newTestingMerkle(t, 140)
+	defer mt1.storage.Close()
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, mt1.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	_, _, rootBytes, err := mt1.Get(mt1.Root())
+	assert.Nil(t, err)
+	childR := parseNodeBytes(rootBytes).ChildR
+	src := &countingNodeSource{NodeSource: NewLocalNodeSource(mt1), corrupt: childR}
+	mt2 := newTestingMerkle(t, 140)
+	defer mt2.storage.Close()
+	err = mt2.Sync(src, mt1.Root())
+	assert.True(t, errors.As(err, &corrupt))
+	assert.Equal(t, childR, corrupt.Hash)
+}
+
+func TestStorageError(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	assert.Nil(t, mt.storage.Close())
+
+	err := mt.Add(newTestBytesLeaf("this is a test leaf", 15))
+	var storageErr *StorageError
+	assert.True(t, errors.As(err, &storageErr))
+	assert.True(t, errors.Is(err, leveldb.ErrClosed))
+}
+
+func TestStorageErrorOnWrite(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	root := mt.Root()
+	// the nodes can be read, but not written
+	assert.Nil(t, mt.storage.SetReadOnly())
+	err := mt.Add(newTestBytesLeaf("this is a test leaf", 15))
+	var storageErr *StorageError
+	assert.True(t, errors.As(err, &storageErr))
+	assert.True(t, errors.Is(err, leveldb.ErrReadOnly))
+	assert.Equal(t, root, mt.Root())
+}
@@ -1,6 +1,6 @@
 module github.com/arnaucube/go-merkletree
 
-go 1.12
+go 1.13
 
 require (
 	github.com/dchest/uniuri v0.0.0-20160212164326-8902c56451e9
@@ -0,0 +1,81 @@
+package merkletree
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// HashPoseidon identifies the Poseidon hash function over the BN254 scalar field
+	HashPoseidon HashFunctionID = 2
+	// HashMiMC7 identifies the MiMC7 hash function over the BN254 scalar field
+	HashMiMC7 HashFunctionID = 3
+
+	// fieldChunkLength is the number of bytes of each field element of the leaf bytes,
+	// so that any chunk is lower than the field modulus
+	fieldChunkLength = 31
+)
+
+// Hasher is the hash function used by a tree for the leafs and the nodes
+type Hasher interface {
+	// ID returns the identifier of the hash function, stored in the Metadata of the tree
+	ID() HashFunctionID
+	// HashBytes returns the hash of the bytes of a leaf, or of its index
+	HashBytes(b []byte) Hash
+	// HashNode returns the hash of a middle node with the childs
+	HashNode(childL, childR Hash) Hash
+}
+
+// Keccak256Hasher is the Hasher of the Keccak256 hash function, the default one of the trees
+var Keccak256Hasher Hasher = keccak256Hasher{}
+
+type keccak256Hasher struct{}
+
+func (keccak256Hasher) ID() HashFunctionID {
+	return HashKeccak256
+}
+
+func (keccak256Hasher) HashBytes(b []byte) Hash {
+	var hash Hash
+	copy(hash[:], crypto.Keccak256(b))
+	return hash
+}
+
+func (keccak256Hasher) HashNode(childL, childR Hash) Hash {
+	var hash Hash
+	copy(hash[:], crypto.Keccak256(childL[:], childR[:]))
+	return hash
+}
+
+// bn254Order is the order of the scalar field of the BN254 curve, the field of the SNARK friendly hashers
+var bn254Order, _ = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+
+// hashToElement returns the field element of the Hash, read as a big endian number
+// reduced by the field modulus
+func hashToElement(h Hash) *big.Int {
+	e := new(big.Int).SetBytes(h[:])
+	return e.Mod(e, bn254Order)
+}
+
+// elementToHash returns the Hash of the field element, as a big endian number
+func elementToHash(e *big.Int) Hash {
+	var h Hash
+	b := e.Bytes()
+	copy(h[len(h)-len(b):], b)
+	return h
+}
+
+// bytesToElements maps the bytes of a leaf to field elements: the first one is the
+// length of the bytes, followed by the big endian numbers of each chunk of 31 bytes
+func bytesToElements(b []byte) []*big.Int {
+	elements := []*big.Int{big.NewInt(int64(len(b)))}
+	for i := 0; i < len(b); i += fieldChunkLength {
+		end := i + fieldChunkLength
+		if end > len(b) {
+			end = len(b)
+		}
+		elements = append(elements, new(big.Int).SetBytes(b[i:end]))
+	}
+	return elements
+}
@@ -0,0 +1,106 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasherVectors(t *testing.T) {
+	vectors := []struct {
+		hasher   Hasher
+		leafHash string
+		nodeHash string
+		root     string
+	}{
+		{
+			Keccak256Hasher,
+			"0x5b384aca0e350140cfbcc86a0eb5d3d4d66d4ad95c4c9e84e6c46e5d4ca49e19",
+			"0x805b21d846b189efaeb0377d6bb0d201b3872a363e607c25088f025b0c6ae1f8",
+			"0x9418fd35bae19de4ab033efaf7cc624adf6a42827e39029d8da13288e9c3170d",
+		},
+		{
+			PoseidonHasher,
+			"0x2ec565184f1db5df670b571ff2d95b00a25b8b7fd9ca2b0683028f9c7fd96e1c",
+			"0x05d360d0a0ff35b99174b7e238e3b2569409ae8d2fd72ec3a69f825a9d078fa6",
+			"0x1c4c636ebb012b35ade56b2728928c219fae625d6f032e5eaff674af9f213daa",
+		},
+		{
+			MiMC7Hasher,
+			"0x05f224001a0c349809770c697707e07db97076b1b435bc19fe0c40d22068dd99",
+			"0x205c9f2435ba5a3704043e726b6f41c92cc25e74233506d55d225ae3189f9745",
+			"0x0a6104e199c64aa12c260e93dd0b7e1aaaab5f5ed20f4317899e1af9bc4a27f5",
+		},
+	}
+	for _, v := range vectors {
+		assert.Equal(t, v.leafHash, v.hasher.HashBytes([]byte("this is a test leaf")).Hex())
+		assert.Equal(t, v.nodeHash, v.hasher.HashNode(HashBytes([]byte("a")), HashBytes([]byte("b"))).Hex())
+
+		mt := newTestingMerkle(t, 140)
+		tree, err := NewWithOptions(mt.storage, 140, Options{Namespace: "vectors", Hasher: v.hasher})
+		assert.Nil(t, err)
+		for i := 0; i < 10; i++ {
+			assert.Nil(t, tree.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+		}
+		assert.Equal(t, v.root, tree.Root().Hex())
+		mt.storage.Close()
+	}
+}
+
+func TestHasherFieldElements(t *testing.T) {
+	// the hashes of the SNARK friendly hashers are field elements
+	for _, h := range []Hasher{PoseidonHasher, MiMC7Hasher} {
+		for i := 0; i < 10; i++ {
+			hash := h.HashBytes([]byte(strconv.Itoa(i) + " this is a test leaf"))
+			assert.True(t, hashToElement(hash).Cmp(bn254Order) < 0)
+			assert.Equal(t, hash, elementToHash(hashToElement(hash)))
+		}
+	}
+	// the length of the bytes is mapped, so the trailing zeros change the hash
+	assert.NotEqual(t, PoseidonHasher.HashBytes([]byte{1}), PoseidonHasher.HashBytes([]byte{1, 0}))
+	assert.NotEqual(t, MiMC7Hasher.HashBytes([]byte{}), MiMC7Hasher.HashBytes([]byte{0}))
+}
+
+func TestHasherTree(t *testing.T) {
+	for _, h := range []Hasher{PoseidonHasher, MiMC7Hasher} {
+		mt := newTestingMerkle(t, 140)
+		tree, err := NewWithOptions(mt.storage, 140, Options{Namespace: "snark", Hasher: h})
+		assert.Nil(t, err)
+		assert.Equal(t, h, tree.Hasher())
+		assert.Equal(t, h.ID(), tree.Metadata().HashFunction)
+
+		leafs := make([]testBytesLeaf, 10)
+		for i := range leafs {
+			leafs[i] = newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)
+			assert.Nil(t, tree.Add(leafs[i]))
+		}
+		assert.Empty(t, tree.Verify(tree.Root()))
+
+		for _, leaf := range leafs {
+			hi := h.HashBytes(leaf.Bytes()[:leaf.IndexLength()])
+			proof, err := tree.GenerateProof(hi)
+			assert.Nil(t, err)
+			assert.True(t, CheckProofWithHasher(h, tree.Root(), proof, hi, h.HashBytes(leaf.Bytes()), 140))
+			assert.True(t, !CheckProof(tree.Root(), proof, hi, h.HashBytes(leaf.Bytes()), 140))
+		}
+
+		// deleting a leaf gives back the root of the tree without it
+		hi := h.HashBytes(leafs[9].Bytes()[:leafs[9].IndexLength()])
+		rootWithout := tree.Root()
+		leaf := newTestBytesLeaf("10 this is a test leaf", 15)
+		assert.Nil(t, tree.Add(leaf))
+		assert.Nil(t, tree.Delete(h.HashBytes(leaf.Bytes()[:leaf.IndexLength()])))
+		assert.Equal(t, rootWithout, tree.Root())
+		assert.Nil(t, tree.Delete(hi))
+		assert.Empty(t, tree.Verify(tree.Root()))
+
+		// the stored tree can only be opened with its Hasher
+		_, err = NewWithOptions(mt.storage, 140, Options{Namespace: "snark"})
+		assert.Equal(t, ErrHashFunctionMismatch, err)
+		reopened, err := NewWithOptions(mt.storage, 140, Options{Namespace: "snark", Hasher: h})
+		assert.Nil(t, err)
+		assert.Equal(t, tree.Root(), reopened.Root())
+		mt.storage.Close()
+	}
+}
@@ -0,0 +1,110 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+)
+
+// Leaf is a generic Value, formed by the index bytes followed by the data bytes
+type Leaf struct {
+	data        []byte
+	indexLength uint32
+}
+
+// NewLeaf returns the Leaf formed by the index and the data
+func NewLeaf(index, data []byte) Leaf {
+	b := make([]byte, 0, len(index)+len(data))
+	b = append(b, index...)
+	b = append(b, data...)
+	return Leaf{
+		data:        b,
+		indexLength: uint32(len(index)),
+	}
+}
+
+// IndexLength returns the length of the index of the Leaf
+func (l Leaf) IndexLength() uint32 {
+	return l.indexLength
+}
+
+// Bytes returns the index followed by the data of the Leaf
+func (l Leaf) Bytes() []byte {
+	return l.data
+}
+
+// Index returns the index of the Leaf
+func (l Leaf) Index() []byte {
+	return l.data[:l.indexLength]
+}
+
+// Data returns the data of the Leaf, without the index
+func (l Leaf) Data() []byte {
+	return l.data[l.indexLength:]
+}
+
+// Put adds to the MT the leaf formed by the index and the data
+func (mt *MerkleTree) Put(index, data []byte) error {
+	return mt.Add(NewLeaf(index, data))
+}
+
+// Lookup returns the data stored in the MT under the index, and if it has been found
+func (mt *MerkleTree) Lookup(index []byte) ([]byte, bool, error) {
+	return mt.LookupContext(context.Background(), index)
+}
+
+// LookupContext returns the data stored under the index as Lookup, returning the error
+// of the context if it is done before the data has been read
+func (mt *MerkleTree) LookupContext(ctx context.Context, index []byte) ([]byte, bool, error) {
+	if len(index) == 0 {
+		return nil, false, ErrEmptyIndex
+	}
+	indexLength, valueBytes, err := mt.getLeafInPos(ctx, mt.root, mt.hasher.HashBytes(index))
+	if err != nil {
+		return nil, false, err
+	}
+	if bytes.Equal(valueBytes, EmptyNodeValue[:]) {
+		return nil, false, nil
+	}
+	// the position can have a leaf with another index in the same path
+	if int(indexLength) != len(index) || !bytes.Equal(valueBytes[:indexLength], index) {
+		return nil, false, nil
+	}
+	data := make([]byte, len(valueBytes)-len(index))
+	copy(data, valueBytes[indexLength:])
+	return data, true, nil
+}
+
+// Prove generates the Merkle Proof of the position of the index for the current
+// root. If the index is in the MT, the proof is checked with the hash of the leaf
+// formed by the index and its data, else with the EmptyNodeValue
+func (mt *MerkleTree) Prove(index []byte) ([]byte, error) {
+	return mt.GenerateProof(mt.hasher.HashBytes(index))
+}
+
+func (mt *MerkleTree) leafsLevel(ctx context.Context, nodeHash Hash, leafs []Leaf) ([]Leaf, error) {
+	if bytes.Equal(nodeHash[:], EmptyNodeValue[:]) {
+		return leafs, nil
+	}
+	nodeType, indexLength, nodeBytes, err := mt.getContext(ctx, nodeHash)
+	if err != nil {
+		return nil, err
+	}
+	switch nodeType {
+	case normalNodeType:
+		node := parseNodeBytes(nodeBytes)
+		if leafs, err = mt.leafsLevel(ctx, node.ChildL, leafs); err != nil {
+			return nil, err
+		}
+		return mt.leafsLevel(ctx, node.ChildR, leafs)
+	case finalNodeType, valueNodeType:
+		data := make([]byte, len(nodeBytes))
+		copy(data, nodeBytes)
+		return append(leafs, Leaf{data: data, indexLength: indexLength}), nil
+	}
+	return leafs, nil
+}
+
+// leafs returns all the leafs of the tree with the given root, from left to right
+func (mt *MerkleTree) leafs(ctx context.Context, root Hash) ([]Leaf, error) {
+	return mt.leafsLevel(ctx, root, nil)
+}
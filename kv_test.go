@@ -0,0 +1,81 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaf(t *testing.T) {
+	leaf := NewLeaf([]byte("this is a test "), []byte("leaf"))
+	assert.Equal(t, uint32(15), leaf.IndexLength())
+	assert.Equal(t, []byte("this is a test leaf"), leaf.Bytes())
+	assert.Equal(t, []byte("this is a test "), leaf.Index())
+	assert.Equal(t, []byte("leaf"), leaf.Data())
+}
+
+func TestPutLookup(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	_, found, err := mt.Lookup([]byte("this is a test "))
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	// same root than adding the testBytesLeaf
+	assert.Nil(t, mt.Put([]byte("this is a test "), []byte("leaf")))
+	assert.Equal(t, "0xb4fdf8a653198f0e179ccb3af7e4fc09d76247f479d6cfc95cd92d6fda589f27", mt.Root().Hex())
+	assert.Nil(t, mt.Put([]byte("this is a secon"), []byte("d test leaf")))
+	assert.Equal(t, "0x8ac95e9c8a6fbd40bb21de7895ee35f9c8f30ca029dbb0972c02344f49462e82", mt.Root().Hex())
+	err = mt.Put([]byte("this is a test "), []byte("leaf"))
+	assert.True(t, errors.Is(err, ErrNodeAlreadyExists))
+	assert.Equal(t, &DuplicateIndexError{HashBytes([]byte("this is a test "))}, err)
+
+	data, found, err := mt.Lookup([]byte("this is a test "))
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("leaf"), data)
+
+	_, found, err = mt.Lookup([]byte("this is a third"))
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestLookupEmpty(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	_, found, err := mt.Lookup([]byte{})
+	assert.Equal(t, ErrEmptyIndex, err)
+	assert.False(t, found)
+	// an index with the bytes of an empty position
+	_, found, err = mt.Lookup(EmptyNodeValue[:])
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	assert.Nil(t, mt.Put([]byte("this is a test "), []byte("leaf")))
+	_, found, err = mt.Lookup([]byte{})
+	assert.Equal(t, ErrEmptyIndex, err)
+	assert.False(t, found)
+	_, found, err = mt.Lookup(EmptyNodeValue[:])
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestProve(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	assert.Nil(t, mt.Put([]byte("this is a test "), []byte("leaf")))
+	assert.Nil(t, mt.Put([]byte("this is a secon"), []byte("d test leaf")))
+
+	proof, err := mt.Prove([]byte("this is a secon"))
+	assert.Nil(t, err)
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001fd8e1a60cdb23c0c7b2cf8462c99fafd905054dccb0ed75e7c8a7d6806749b6b", BytesToHex(proof))
+	assert.True(t, CheckProof(mt.Root(), proof, HashBytes([]byte("this is a secon")), HashBytes([]byte("this is a second test leaf")), mt.NumLevels()))
+
+	proof, err = mt.Prove([]byte("this is a third"))
+	assert.Nil(t, err)
+	assert.True(t, CheckProof(mt.Root(), proof, HashBytes([]byte("this is a third")), EmptyNodeValue, mt.NumLevels()))
+}
@@ -2,7 +2,9 @@ package merkletree
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/syndtr/goleveldb/leveldb"
 )
@@ -18,11 +20,30 @@ const (
 	valueNodeType = 03
 	// RootNodeType indicates the type of a root Node
 	rootNodeType = 04
+	// metadataNodeType indicates the type of the Node that stores the Metadata of the tree
+	metadataNodeType = 05
+
+	// minNumLevels is the minimum number of levels of a tree, the root and the leafs
+	minNumLevels = 2
+	// maxNumLevels is the maximum number of levels of a tree, where the path uses all the bits of the Hash
+	maxNumLevels = 8*len(EmptyNodeValue) + 1
 )
 
 var (
 	// ErrNodeAlreadyExists is an error that indicates that a node already exists in the merkletree database
 	ErrNodeAlreadyExists = errors.New("node already exists")
+	// ErrPathCollision is an error that indicates that the path of a leaf is used by a leaf with a different index
+	ErrPathCollision = errors.New("path used by a leaf with a different index")
+	// ErrEmptyIndex is an error that indicates that the index of a value has length 0
+	ErrEmptyIndex = errors.New("index of the value is empty")
+	// ErrIndexLengthOutOfRange is an error that indicates that the index length of a value is greater than its length
+	ErrIndexLengthOutOfRange = errors.New("index length greater than the length of the value")
+	// ErrEmptyValue is an error that indicates that a value, or its hash, is equal to the EmptyNodeValue
+	ErrEmptyValue = errors.New("value can not be distinguished from an empty node")
+	// ErrInvalidNumLevels is an error that indicates that the number of levels is out of the supported range
+	ErrInvalidNumLevels = fmt.Errorf("numLevels must be between %d and %d", minNumLevels, maxNumLevels)
+	// ErrNumLevelsMismatch is an error that indicates that the stored tree has a different number of levels
+	ErrNumLevelsMismatch = errors.New("numLevels different from the one of the stored tree")
 	rootNodeValue        = HashBytes([]byte("root"))
 	// EmptyNodeValue is a [32]byte EmptyNodeValue array, all to zero
 	EmptyNodeValue = Hash{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
@@ -37,37 +58,130 @@ type Value interface {
 	Bytes() []byte       // returns the value in byte array representation
 }
 
+// validateValue checks that the Value can be a leaf of a tree with the Hasher: the index can
+// not be empty nor longer than the value, and neither the value nor its hash can be the EmptyNodeValue
+func validateValue(h Hasher, v Value) error {
+	b := v.Bytes()
+	if v.IndexLength() == 0 {
+		return ErrEmptyIndex
+	}
+	if uint64(v.IndexLength()) > uint64(len(b)) {
+		return ErrIndexLengthOutOfRange
+	}
+	if bytes.Equal(b, EmptyNodeValue[:]) {
+		return ErrEmptyValue
+	}
+	if leafHash := h.HashBytes(b); bytes.Equal(leafHash[:], EmptyNodeValue[:]) {
+		return ErrEmptyValue
+	}
+	return nil
+}
+
+// Options are the optional parameters of a Merkle Tree
+type Options struct {
+	// Namespace separates the tree from the other trees of the same database, each one
+	// with its own root and metadata. The trees created by New have an empty Namespace
+	Namespace string
+	// SharedNodes stores the nodes of the tree without the prefix of the Namespace, so
+	// the nodes that are in several trees of the database are stored only once. A stored
+	// tree can only be opened with the SharedNodes it was created with
+	SharedNodes bool
+	// Hasher is the hash function of the leafs and the nodes of the tree, Keccak256Hasher
+	// when it is nil. A stored tree can only be opened with the Hasher it was created with
+	Hasher Hasher
+}
+
 //MerkleTree struct with the main elements of the Merkle Tree
 type MerkleTree struct {
 	// sync.RWMutex
-	storage   *leveldb.DB
-	root      Hash
-	numLevels int // Height of the Merkle Tree, number of levels
+	storage    *leveldb.DB
+	prefix     []byte // prefix of the keys of the root and the metadata in the database
+	nodePrefix []byte // prefix of the keys of the nodes in the database
+	root       Hash
+	hasher     Hasher            // hash function of the leafs and the nodes
+	numLevels  int               // Height of the Merkle Tree, number of levels
+	stats      *Stats            // stats updated on each Add, nil if they are not tracked
+	metadata   *Metadata         // metadata stored in the database
+	staged     map[string][]byte // writes not yet in the database, nil if the writes are not staged
 }
 
-// New generates a new Merkle Tree
+// New generates a new Merkle Tree, or opens the one stored in the database, that has
+// to have the same number of levels
 func New(storage *leveldb.DB, numLevels int) (*MerkleTree, error) {
+	return NewWithOptions(storage, numLevels, Options{})
+}
+
+// NewWithOptions generates a new Merkle Tree with the given Options, or opens the one
+// stored in the database with the same Namespace, that has to have the same number of levels
+func NewWithOptions(storage *leveldb.DB, numLevels int, opts Options) (*MerkleTree, error) {
+	if numLevels < minNumLevels || numLevels > maxNumLevels {
+		return nil, ErrInvalidNumLevels
+	}
 	var mt MerkleTree
 	mt.storage = storage
 	mt.numLevels = numLevels
-	var err error
-	_, _, rootHash, err := mt.Get(rootNodeValue)
+	mt.hasher = opts.Hasher
+	if mt.hasher == nil {
+		mt.hasher = Keccak256Hasher
+	}
+	if opts.Namespace != "" {
+		namespaceHash := HashBytes([]byte(opts.Namespace))
+		mt.prefix = namespaceHash[:]
+		if !opts.SharedNodes {
+			mt.nodePrefix = mt.prefix
+		}
+	}
+	if err := mt.checkMetadata(); err != nil {
+		return nil, err
+	}
+	_, _, rootHash, err := mt.get(mt.treeKey(rootNodeValue))
 	if err != nil {
 		mt.root = EmptyNodeValue
-		err = mt.Insert(rootNodeValue, rootNodeType, 0, mt.root[:])
+		err = mt.put(mt.treeKey(rootNodeValue), rootNodeType, 0, mt.root[:])
 		if err != nil {
 			return nil, err
 		}
 	}
 	copy(mt.root[:], rootHash)
+	if mt.metadata == nil {
+		if err := mt.initMetadata(); err != nil {
+			return nil, err
+		}
+	}
 	return &mt, nil
 }
 
+// storeRoot stores the current root in the database, updating the number of leafs of
+// the Metadata with the ones added by the last operation
+func (mt *MerkleTree) storeRoot(addedLeafs int) error {
+	return mt.storeRootAndLeafCount(mt.metadata.LeafCount + uint64(addedLeafs))
+}
+
+// storeRootAndLeafCount stores the current root and the Metadata with the number of
+// leafs of the root in a single write, so the stored ones are always consistent
+func (mt *MerkleTree) storeRootAndLeafCount(leafCount uint64) error {
+	metadata := *mt.metadata
+	metadata.LeafCount = leafCount
+	batch := new(leveldb.Batch)
+	batch.Put(mt.treeKey(rootNodeValue), encodeNode(rootNodeType, 0, mt.root[:]))
+	batch.Put(mt.treeKey(metadataNodeValue), encodeNode(metadataNodeType, 0, metadata.Bytes()))
+	if err := mt.writeBatch(batch); err != nil {
+		return err
+	}
+	mt.metadata.LeafCount = leafCount
+	return nil
+}
+
 // Root returns the merkletree.Root
 func (mt *MerkleTree) Root() Hash {
 	return mt.root
 }
 
+// Hasher returns the Hasher of the leafs and the nodes of the MT
+func (mt *MerkleTree) Hasher() Hasher {
+	return mt.hasher
+}
+
 // NumLevels returns the merkletree.NumLevels
 func (mt *MerkleTree) NumLevels() int {
 	return mt.numLevels
@@ -75,30 +189,49 @@ func (mt *MerkleTree) NumLevels() int {
 
 // Add adds the leaf to the MT
 func (mt *MerkleTree) Add(v Value) error {
-	// add the leaf that we are adding
-	mt.Insert(HashBytes(v.Bytes()), valueNodeType, v.IndexLength(), v.Bytes())
+	return mt.AddContext(context.Background(), v)
+}
+
+// AddContext adds the leaf to the MT as Add, returning the error of the context if it
+// is done before the path of the leaf has been read, without changing the root
+func (mt *MerkleTree) AddContext(ctx context.Context, v Value) error {
+	_, err := mt.add(ctx, v)
+	return err
+}
 
-	hi := HashBytes(v.Bytes()[:v.IndexLength()])
+// add adds the leaf to the MT, returning the siblings of its new position, from the root
+func (mt *MerkleTree) add(ctx context.Context, v Value) ([]Hash, error) {
+	if err := validateValue(mt.hasher, v); err != nil {
+		return nil, err
+	}
+	hi := mt.hasher.HashBytes(v.Bytes()[:v.IndexLength()])
 	path := getPath(mt.numLevels, hi)
 
 	nodeHash := mt.root
 	var siblings []Hash
 	for i := mt.numLevels - 2; i >= 0; i-- {
-		nodeType, indexLength, nodeBytes, err := mt.Get(nodeHash)
+		nodeType, indexLength, nodeBytes, err := mt.getContext(ctx, nodeHash)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if nodeType == byte(finalNodeType) {
-			hiChild := HashBytes(nodeBytes[:indexLength])
+			hiChild := mt.hasher.HashBytes(nodeBytes[:indexLength])
 			pathChild := getPath(mt.numLevels, hiChild)
 			posDiff := comparePaths(pathChild, path)
 			if posDiff == -1 {
-				return ErrNodeAlreadyExists
+				return nil, leafCollision(hi, hiChild)
+			}
+			if err := mt.insertValue(v); err != nil {
+				return nil, err
+			}
+			finalNode1Hash := calcHashFromLeafAndLevel(mt.hasher, posDiff, pathChild, mt.hasher.HashBytes(nodeBytes))
+			if err := mt.Insert(finalNode1Hash, finalNodeType, indexLength, nodeBytes); err != nil {
+				return nil, err
+			}
+			finalNode2Hash := calcHashFromLeafAndLevel(mt.hasher, posDiff, path, mt.hasher.HashBytes(v.Bytes()))
+			if err := mt.Insert(finalNode2Hash, finalNodeType, v.IndexLength(), v.Bytes()); err != nil {
+				return nil, err
 			}
-			finalNode1Hash := calcHashFromLeafAndLevel(posDiff, pathChild, HashBytes(nodeBytes))
-			mt.Insert(finalNode1Hash, finalNodeType, indexLength, nodeBytes)
-			finalNode2Hash := calcHashFromLeafAndLevel(posDiff, path, HashBytes(v.Bytes()))
-			mt.Insert(finalNode2Hash, finalNodeType, v.IndexLength(), v.Bytes())
 			// now the parent
 			var parentNode treeNode
 			if path[posDiff] {
@@ -114,11 +247,17 @@ func (mt *MerkleTree) Add(v Value) error {
 			}
 			siblings = append(siblings, getEmptiesBetweenIAndPosHash(mt, i, posDiff+1)...)
 
-			if mt.root, err = mt.replaceLeaf(siblings, path[posDiff+1:], parentNode.Ht(), normalNodeType, 0, parentNode.Bytes()); err != nil {
-				return err
+			root, err := mt.replaceLeaf(siblings, path[posDiff+1:], parentNode.Ht(mt.hasher), normalNodeType, 0, parentNode.Bytes())
+			if err != nil {
+				return nil, err
 			}
-			mt.Insert(rootNodeValue, rootNodeType, 0, mt.root[:])
-			return nil
+			mt.root = root
+			// the final node goes down to the level of posDiff, with the new one as sibling
+			mt.trackStats(finalNodeType, mt.numLevels-2-i, nodeBytes, -1)
+			mt.trackStats(finalNodeType, mt.numLevels-1-posDiff, nodeBytes, 1)
+			mt.trackStats(finalNodeType, mt.numLevels-1-posDiff, v.Bytes(), 1)
+			mt.trackStats(normalNodeType, 0, parentNode.Bytes(), i-posDiff+1)
+			return append(siblings, finalNode1Hash), mt.storeRoot(1)
 		}
 		node := parseNodeBytes(nodeBytes)
 		var sibling Hash
@@ -133,64 +272,89 @@ func (mt *MerkleTree) Add(v Value) error {
 
 		if bytes.Equal(nodeHash[:], EmptyNodeValue[:]) {
 			// if the node is EmptyNodeValue, the leaf data will go directly at that height, as a Final Node
+			if err := mt.insertValue(v); err != nil {
+				return nil, err
+			}
 			if i == mt.numLevels-2 && bytes.Equal(siblings[len(siblings)-1][:], EmptyNodeValue[:]) {
 				// if the pt node is the unique in the tree, just put it into the root node
 				// this means to be in i==mt.NumLevels-2 && nodeHash==EmptyNodeValue
-				finalNodeHash := calcHashFromLeafAndLevel(i+1, path, HashBytes(v.Bytes()))
-				mt.Insert(finalNodeHash, finalNodeType, v.IndexLength(), v.Bytes())
+				finalNodeHash := calcHashFromLeafAndLevel(mt.hasher, i+1, path, mt.hasher.HashBytes(v.Bytes()))
+				if err := mt.Insert(finalNodeHash, finalNodeType, v.IndexLength(), v.Bytes()); err != nil {
+					return nil, err
+				}
 				mt.root = finalNodeHash
-				mt.Insert(rootNodeValue, rootNodeType, 0, mt.root[:])
-				return nil
+				mt.trackStats(finalNodeType, 0, v.Bytes(), 1)
+				return nil, mt.storeRoot(1)
 			}
-			finalNodeHash := calcHashFromLeafAndLevel(i, path, HashBytes(v.Bytes()))
-			if mt.root, err = mt.replaceLeaf(siblings, path[i:], finalNodeHash, finalNodeType, v.IndexLength(), v.Bytes()); err != nil {
-				return err
+			finalNodeHash := calcHashFromLeafAndLevel(mt.hasher, i, path, mt.hasher.HashBytes(v.Bytes()))
+			root, err := mt.replaceLeaf(siblings, path[i:], finalNodeHash, finalNodeType, v.IndexLength(), v.Bytes())
+			if err != nil {
+				return nil, err
 			}
-			mt.Insert(rootNodeValue, rootNodeType, 0, mt.root[:])
-			return nil
+			mt.root = root
+			mt.trackStats(finalNodeType, mt.numLevels-1-i, v.Bytes(), 1)
+			return siblings, mt.storeRoot(1)
 		}
 	}
 
-	var err error
-	mt.root, err = mt.replaceLeaf(siblings, path, HashBytes(v.Bytes()), valueNodeType, v.IndexLength(), v.Bytes())
+	// there is a leaf at the bottom of the path, that is never replaced
+	_, indexLength, nodeBytes, err := mt.getContext(ctx, nodeHash)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	mt.Insert(rootNodeValue, rootNodeType, 0, mt.root[:])
-	return nil
+	return nil, leafCollision(hi, mt.hasher.HashBytes(nodeBytes[:indexLength]))
+}
+
+// insertValue stores the leaf as a value node. It is only called once the leaf can be
+// added, and before its final node, that replaces the value node when the leaf is at
+// the bottom level, as both have the same hash
+func (mt *MerkleTree) insertValue(v Value) error {
+	return mt.Insert(mt.hasher.HashBytes(v.Bytes()), valueNodeType, v.IndexLength(), v.Bytes())
 }
 
 // GenerateProof generates the Merkle Proof from a given leafHash for the current root
 func (mt *MerkleTree) GenerateProof(hi Hash) ([]byte, error) {
+	return mt.generateProof(context.Background(), mt.root, hi)
+}
+
+// GenerateProofContext generates the Merkle Proof as GenerateProof, returning the error
+// of the context if it is done before the proof has been generated
+func (mt *MerkleTree) GenerateProofContext(ctx context.Context, hi Hash) ([]byte, error) {
+	return mt.generateProof(ctx, mt.root, hi)
+}
+
+// generateProof generates the Merkle Proof from a given leafHash for the given root
+func (mt *MerkleTree) generateProof(ctx context.Context, root Hash, hi Hash) ([]byte, error) {
 	var empties [32]byte
 
 	path := getPath(mt.numLevels, hi)
 	var siblings []Hash
-	nodeHash := mt.root
+	nodeHash := root
 
 	for level := 0; level < mt.numLevels-1; level++ {
-		nodeType, indexLength, nodeBytes, err := mt.Get(nodeHash)
+		nodeType, indexLength, nodeBytes, err := mt.getContext(ctx, nodeHash)
 		if err != nil {
 			return nil, err
 		}
 		if nodeType == byte(finalNodeType) {
-			realValueInPos, err := mt.GetValueInPos(hi)
+			_, realValueInPos, err := mt.getLeafInPos(ctx, root, hi)
 			if err != nil {
 				return nil, err
 			}
 			if bytes.Equal(realValueInPos[:], EmptyNodeValue[:]) {
 				// go until the path is different, then get the nodes between this FinalNode and the node in the diffPath, they will be the siblings of the merkle proof
-				leafHi := HashBytes(nodeBytes[:indexLength]) // hi of element that was in the end of the branch (the finalNode)
+				leafHi := mt.hasher.HashBytes(nodeBytes[:indexLength]) // hi of element that was in the end of the branch (the finalNode)
 				pathChild := getPath(mt.numLevels, leafHi)
 
 				// get the position where the path is different
 				posDiff := comparePaths(pathChild, path)
 				if posDiff == -1 {
-					return nil, ErrNodeAlreadyExists
+					// the position was found empty, so the final node can not be in the same path
+					return nil, &CorruptNodeError{nodeHash, "final node in the position of another leaf"}
 				}
 
 				if posDiff != mt.NumLevels()-1-level {
-					sibling := calcHashFromLeafAndLevel(posDiff, pathChild, HashBytes(nodeBytes))
+					sibling := calcHashFromLeafAndLevel(mt.hasher, posDiff, pathChild, mt.hasher.HashBytes(nodeBytes))
 					setbitmap(empties[:], uint(mt.NumLevels()-2-posDiff))
 					siblings = append([]Hash{sibling}, siblings...)
 				}
@@ -224,24 +388,36 @@ func (mt *MerkleTree) GenerateProof(hi Hash) ([]byte, error) {
 
 // GetValueInPos returns the merkletree value in the position of the Hash of the Index (Hi)
 func (mt *MerkleTree) GetValueInPos(hi Hash) ([]byte, error) {
+	return mt.GetValueInPosContext(context.Background(), hi)
+}
+
+// GetValueInPosContext returns the value in the position of the Hi as GetValueInPos,
+// returning the error of the context if it is done before the value has been read
+func (mt *MerkleTree) GetValueInPosContext(ctx context.Context, hi Hash) ([]byte, error) {
+	_, valueBytes, err := mt.getLeafInPos(ctx, mt.root, hi)
+	return valueBytes, err
+}
+
+// getLeafInPos returns the index length and the value in the position of the Hash of the Index (Hi) for the given root
+func (mt *MerkleTree) getLeafInPos(ctx context.Context, root Hash, hi Hash) (uint32, []byte, error) {
 	path := getPath(mt.numLevels, hi)
-	nodeHash := mt.root
+	nodeHash := root
 	for i := mt.numLevels - 2; i >= 0; i-- {
-		nodeType, indexLength, nodeBytes, err := mt.Get(nodeHash)
+		nodeType, indexLength, nodeBytes, err := mt.getContext(ctx, nodeHash)
 		if err != nil {
-			return nodeBytes, err
+			return 0, nodeBytes, err
 		}
 		if nodeType == byte(finalNodeType) {
 			// check if nodeBytes path is different of hi
 			index := nodeBytes[:indexLength]
-			nodeHi := HashBytes(index)
+			nodeHi := mt.hasher.HashBytes(index)
 			nodePath := getPath(mt.numLevels, nodeHi)
 			posDiff := comparePaths(path, nodePath)
 			// if is different, return an EmptyNodeValue, else return the nodeBytes
 			if posDiff != -1 {
-				return EmptyNodeValue[:], nil
+				return 0, EmptyNodeValue[:], nil
 			}
-			return nodeBytes, nil
+			return indexLength, nodeBytes, nil
 		}
 		node := parseNodeBytes(nodeBytes)
 		if !path[i] {
@@ -250,14 +426,14 @@ func (mt *MerkleTree) GetValueInPos(hi Hash) ([]byte, error) {
 			nodeHash = node.ChildR
 		}
 	}
-	_, _, valueBytes, err := mt.Get(nodeHash)
+	_, indexLength, valueBytes, err := mt.getContext(ctx, nodeHash)
 	if err != nil {
-		return valueBytes, err
+		return 0, valueBytes, err
 	}
-	return valueBytes, nil
+	return indexLength, valueBytes, nil
 }
 
-func calcHashFromLeafAndLevel(untilLevel int, path []bool, leafHash Hash) Hash {
+func calcHashFromLeafAndLevel(h Hasher, untilLevel int, path []bool, leafHash Hash) Hash {
 	nodeCurrLevel := leafHash
 	for i := 0; i < untilLevel; i++ {
 		if path[i] {
@@ -265,13 +441,13 @@ func calcHashFromLeafAndLevel(untilLevel int, path []bool, leafHash Hash) Hash {
 				ChildL: EmptyNodeValue,
 				ChildR: nodeCurrLevel,
 			}
-			nodeCurrLevel = node.Ht()
+			nodeCurrLevel = node.Ht(h)
 		} else {
 			node := treeNode{
 				ChildL: nodeCurrLevel,
 				ChildR: EmptyNodeValue,
 			}
-			nodeCurrLevel = node.Ht()
+			nodeCurrLevel = node.Ht(h)
 		}
 	}
 	return nodeCurrLevel
@@ -279,7 +455,9 @@ func calcHashFromLeafAndLevel(untilLevel int, path []bool, leafHash Hash) Hash {
 
 func (mt *MerkleTree) replaceLeaf(siblings []Hash, path []bool, newLeafHash Hash, nodetype byte, indexLength uint32, newLeafValue []byte) (Hash, error) {
 	// add the new leaf
-	mt.Insert(newLeafHash, nodetype, indexLength, newLeafValue)
+	if err := mt.Insert(newLeafHash, nodetype, indexLength, newLeafValue); err != nil {
+		return EmptyNodeValue, err
+	}
 	currNode := newLeafHash
 	// here the path is only the path[posDiff+1]
 	for i := 0; i < len(siblings); i++ {
@@ -288,16 +466,20 @@ func (mt *MerkleTree) replaceLeaf(siblings []Hash, path []bool, newLeafHash Hash
 				ChildL: currNode,
 				ChildR: siblings[len(siblings)-1-i],
 			}
-			mt.Insert(node.Ht(), normalNodeType, 0, node.Bytes())
-			currNode = node.Ht()
+			if err := mt.Insert(node.Ht(mt.hasher), normalNodeType, 0, node.Bytes()); err != nil {
+				return EmptyNodeValue, err
+			}
+			currNode = node.Ht(mt.hasher)
 		} else {
 
 			node := treeNode{
 				ChildL: siblings[len(siblings)-1-i],
 				ChildR: currNode,
 			}
-			mt.Insert(node.Ht(), normalNodeType, 0, node.Bytes())
-			currNode = node.Ht()
+			if err := mt.Insert(node.Ht(mt.hasher), normalNodeType, 0, node.Bytes()); err != nil {
+				return EmptyNodeValue, err
+			}
+			currNode = node.Ht(mt.hasher)
 		}
 	}
 
@@ -306,9 +488,20 @@ func (mt *MerkleTree) replaceLeaf(siblings []Hash, path []bool, newLeafHash Hash
 
 // CheckProof validates the Merkle Proof for the leafHash and root
 func CheckProof(root Hash, proof []byte, hi Hash, ht Hash, numLevels int) bool {
+	return CheckProofWithHasher(Keccak256Hasher, root, proof, hi, ht, numLevels)
+}
+
+// CheckProofWithHasher validates the Merkle Proof for the leafHash and root of a tree with the Hasher
+func CheckProofWithHasher(h Hasher, root Hash, proof []byte, hi Hash, ht Hash, numLevels int) bool {
 	var empties [32]byte
-	copy(empties[:], proof[:len(empties)])
 	hashLen := len(EmptyNodeValue)
+	if numLevels < minNumLevels || numLevels > maxNumLevels {
+		return false
+	}
+	if len(proof) < len(empties) || (len(proof)-len(empties))%hashLen != 0 {
+		return false
+	}
+	copy(empties[:], proof[:len(empties)])
 
 	var siblings []Hash
 	for i := len(empties); i < len(proof); i += hashLen {
@@ -324,6 +517,9 @@ func CheckProof(root Hash, proof []byte, hi Hash, ht Hash, numLevels int) bool {
 	for level := numLevels - 2; level >= 0; level-- {
 		var sibling Hash
 		if testbitmap(empties[:], uint(level)) {
+			if siblingUsedPos >= len(siblings) {
+				return false
+			}
 			sibling = siblings[siblingUsedPos]
 			siblingUsedPos++
 		} else {
@@ -346,7 +542,7 @@ func CheckProof(root Hash, proof []byte, hi Hash, ht Hash, numLevels int) bool {
 		if bytes.Equal(nodeHash[:], EmptyNodeValue[:]) && bytes.Equal(sibling[:], EmptyNodeValue[:]) {
 			nodeHash = EmptyNodeValue
 		} else {
-			nodeHash = node.Ht()
+			nodeHash = node.Ht(h)
 		}
 	}
 	return bytes.Equal(nodeHash[:], root[:])
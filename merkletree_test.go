@@ -1,7 +1,10 @@
 package merkletree
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strconv"
 	"testing"
@@ -129,7 +132,9 @@ func TestAddLeafsCollision(t *testing.T) {
 	assert.Nil(t, mt.Add(leaf))
 
 	root1 := mt.Root()
-	assert.EqualError(t, mt.Add(leaf), ErrNodeAlreadyExists.Error())
+	err := mt.Add(leaf)
+	assert.True(t, errors.Is(err, ErrNodeAlreadyExists))
+	assert.Equal(t, &DuplicateIndexError{HashBytes(leaf.Bytes()[:leaf.IndexLength()])}, err)
 
 	assert.Equal(t, root1.Hex(), mt.Root().Hex())
 }
@@ -348,3 +353,222 @@ func TestVector140(t *testing.T) {
 		}
 	}
 }
+
+func TestNumLevels(t *testing.T) {
+	db, err := leveldb.OpenFile("tmp/db"+uniuri.New()+"-"+fmt.Sprint(time.Now().Unix()), nil)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	_, err = New(db, 1)
+	assert.Equal(t, ErrInvalidNumLevels, err)
+	_, err = New(db, 258)
+	assert.Equal(t, ErrInvalidNumLevels, err)
+
+	mt, err := New(db, 140)
+	assert.Nil(t, err)
+	assert.Nil(t, mt.Add(newTestLeaf("iden3.io", "typespec", []byte("c1"))))
+
+	// the tree can only be opened again with the same numLevels
+	_, err = New(db, 141)
+	assert.True(t, errors.Is(err, ErrNumLevelsMismatch))
+	assert.Equal(t, &NumLevelsMismatchError{141, 140}, err)
+	mt, err = New(db, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, "0x9d3c407ff02c813cd474c0a6366b4f7c58bf417a38268f7a0d73a8bca2490b9b", mt.Root().Hex())
+}
+
+func TestMinMaxNumLevels(t *testing.T) {
+	for _, numLevels := range []int{2, 257} {
+		mt := newTestingMerkle(t, numLevels)
+		defer mt.storage.Close()
+
+		var leafs []testBytesLeaf
+		for i := 0; i < 50; i++ {
+			leaf := newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)
+			if err := mt.Add(leaf); err == nil {
+				leafs = append(leafs, leaf)
+			}
+		}
+		assert.Equal(t, 0, len(mt.Verify(mt.Root())))
+		for _, leaf := range leafs {
+			proof, err := mt.GenerateProof(leaf.Hi())
+			assert.Nil(t, err)
+			valueInPos, err := mt.GetValueInPos(leaf.Hi())
+			assert.Nil(t, err)
+			assert.True(t, CheckProof(mt.Root(), proof, leaf.Hi(), HashBytes(valueInPos), numLevels))
+		}
+		if numLevels == 257 {
+			assert.Equal(t, 50, len(leafs))
+			leaf := newTestBytesLeaf("this is not in the tree", 15)
+			proof, err := mt.GenerateProof(leaf.Hi())
+			assert.Nil(t, err)
+			assert.True(t, CheckProof(mt.Root(), proof, leaf.Hi(), EmptyNodeValue, numLevels))
+		}
+	}
+}
+
+func TestCheckProofNumLevelsOutOfRange(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	leaf := newTestBytesLeaf("this is a test leaf", 15)
+	assert.Nil(t, mt.Add(leaf))
+	proof, err := mt.GenerateProof(leaf.Hi())
+	assert.Nil(t, err)
+	vp, err := mt.GenerateValueProof(leaf)
+	assert.Nil(t, err)
+	mp, err := mt.GenerateMultiProof([]Hash{leaf.Hi()})
+	assert.Nil(t, err)
+	cp, err := mt.GenerateConsistencyProof(mt.Root(), mt.Root())
+	assert.Nil(t, err)
+	for _, numLevels := range []int{-1, 0, 1, 258, 300} {
+		assert.False(t, CheckProof(mt.Root(), proof, leaf.Hi(), HashBytes(leaf.Bytes()), numLevels))
+		assert.False(t, CheckValueProof(mt.Root(), vp, numLevels))
+		assert.False(t, CheckMultiProof(mt.Root(), mp, []Hash{leaf.Hi()}, []Hash{HashBytes(leaf.Bytes())}, numLevels))
+		assert.False(t, CheckConsistencyProof(mt.Root(), mt.Root(), cp, numLevels))
+	}
+}
+
+func TestContextCancelled(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, mt.AddContext(context.Background(), newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	root := mt.Root()
+	leaf := newTestBytesLeaf("this is a test leaf", 15)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, mt.AddContext(ctx, leaf))
+	assert.Equal(t, root, mt.Root())
+	_, err := mt.GenerateProofContext(ctx, leaf.Hi())
+	assert.Equal(t, context.Canceled, err)
+	_, err = mt.GetValueInPosContext(ctx, leaf.Hi())
+	assert.Equal(t, context.Canceled, err)
+	_, _, err = mt.LookupContext(ctx, []byte("0 this is a tes"))
+	assert.Equal(t, context.Canceled, err)
+	_, err = mt.StatsContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+	_, err = mt.VerifyContext(ctx, root)
+	assert.Equal(t, context.Canceled, err)
+	_, _, _, err = mt.DiffContext(ctx, EmptyNodeValue, root)
+	assert.Equal(t, context.Canceled, err)
+	_, err = mt.GenerateMultiProofContext(ctx, []Hash{leaf.Hi()})
+	assert.Equal(t, context.Canceled, err)
+	_, err = mt.GenerateConsistencyProofContext(ctx, root, root)
+	assert.Equal(t, context.Canceled, err)
+	_, err = mt.GenerateProofDeltaContext(ctx, leaf.Hi(), root, root)
+	assert.Equal(t, context.Canceled, err)
+	var w bytes.Buffer
+	assert.Equal(t, context.Canceled, mt.JSONMTContext(ctx, &w, 3))
+	assert.Equal(t, context.Canceled, mt.JSONFullMTContext(ctx, &w))
+	assert.Equal(t, context.Canceled, mt.GraphvizMTContext(ctx, &w, 3))
+	assert.Equal(t, context.Canceled, mt.GraphvizFullMTContext(ctx, &w))
+
+	mt2 := newTestingMerkle(t, 140)
+	defer mt2.storage.Close()
+	assert.Equal(t, context.Canceled, mt2.SyncContext(ctx, NewLocalNodeSource(mt), root))
+	assert.Equal(t, EmptyNodeValue, mt2.Root())
+
+	// the operations that have not been cancelled are the same than without context
+	proof, err := mt.GenerateProofContext(context.Background(), leaf.Hi())
+	assert.Nil(t, err)
+	assert.True(t, CheckProof(root, proof, leaf.Hi(), EmptyNodeValue, mt.NumLevels()))
+	incs, err := mt.VerifyContext(context.Background(), root)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(incs))
+}
+
+func TestAddPathCollision(t *testing.T) {
+	mt := newTestingMerkle(t, 4)
+	defer mt.storage.Close()
+
+	// leafLike returns a leaf whose path compared with the one of the leaf is different
+	// only in posDiff, or the same path when posDiff is -1
+	leafLike := func(leaf Leaf, posDiff int) Leaf {
+		path := getPath(4, HashBytes(leaf.Index()))
+		for i := 0; ; i++ {
+			other := NewLeaf([]byte(strconv.Itoa(i)+" other index"), []byte("data"))
+			if comparePaths(getPath(4, HashBytes(other.Index())), path) == posDiff {
+				return other
+			}
+		}
+	}
+	leaf := NewLeaf([]byte("index"), []byte("data"))
+	collision := leafLike(leaf, -1)
+
+	// collision with a final node
+	assert.Nil(t, mt.Add(leaf))
+	root := mt.Root()
+	err := mt.Add(collision)
+	assert.Equal(t, &PathCollisionError{HashBytes(collision.Index()), HashBytes(leaf.Index())}, err)
+	assert.True(t, errors.Is(err, ErrPathCollision))
+	assert.False(t, errors.Is(err, ErrNodeAlreadyExists))
+	assert.Equal(t, root, mt.Root())
+
+	// collision at the bottom of the tree, where the leaf is never replaced
+	assert.Nil(t, mt.Add(leafLike(leaf, 0)))
+	root = mt.Root()
+	err = mt.Add(collision)
+	assert.True(t, errors.Is(err, ErrPathCollision))
+	assert.Equal(t, root, mt.Root())
+	err = mt.Add(NewLeaf([]byte("index"), []byte("other data")))
+	assert.Equal(t, &DuplicateIndexError{HashBytes(leaf.Index())}, err)
+	assert.Equal(t, root, mt.Root())
+	data, found, err := mt.Lookup([]byte("index"))
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("data"), data)
+	assert.Equal(t, uint64(2), mt.Metadata().LeafCount)
+}
+
+func TestAddInvalidValue(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	assert.Equal(t, ErrEmptyIndex, mt.Add(vt{[]byte("this is a test leaf"), 0}))
+	assert.Equal(t, ErrIndexLengthOutOfRange, mt.Add(vt{[]byte("this is a test leaf"), 20}))
+	assert.Equal(t, ErrIndexLengthOutOfRange, mt.Add(vt{nil, 1}))
+	zeros := make([]byte, 32)
+	assert.Equal(t, ErrEmptyValue, mt.Add(vt{zeros, 1}))
+	assert.Equal(t, EmptyNodeValue, mt.Root())
+	assert.Equal(t, uint64(0), mt.Metadata().LeafCount)
+
+	// the index can be the whole value
+	assert.Nil(t, mt.Add(vt{[]byte("this is a test leaf"), 19}))
+	assert.Equal(t, ErrIndexLengthOutOfRange, mt.Update(vt{[]byte("this is a test leaf"), 20}))
+	_, err := mt.GenerateValueProof(vt{zeros, 1})
+	assert.Equal(t, ErrEmptyValue, err)
+	// the values with zeros that are not the EmptyNodeValue are valid
+	assert.Nil(t, mt.Add(vt{make([]byte, 31), 1}))
+	assert.Nil(t, mt.Add(vt{make([]byte, 33), 2}))
+}
+
+func TestAddExistingLeafs(t *testing.T) {
+	mt := newTestingMerkle(t, 4)
+	defer mt.storage.Close()
+
+	var leafs []Leaf
+	for i := 0; i < 20; i++ {
+		leaf := NewLeaf([]byte(strconv.Itoa(i)+" index"), []byte("data"))
+		if mt.Add(leaf) == nil {
+			leafs = append(leafs, leaf)
+		}
+	}
+	root := mt.Root()
+	s, err := mt.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, s.ValueNodes)
+
+	// the rejected leafs do not write any node
+	for _, leaf := range leafs {
+		assert.True(t, errors.Is(mt.Add(leaf), ErrNodeAlreadyExists))
+	}
+	assert.Equal(t, root, mt.Root())
+	reAdded, err := mt.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, s, reAdded)
+	assert.Empty(t, mt.Verify(mt.Root()))
+}
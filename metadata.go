@@ -0,0 +1,139 @@
+package merkletree
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// HashFunctionID identifies the hash function used by a tree
+type HashFunctionID byte
+
+const (
+	// HashKeccak256 identifies the Keccak256 hash function
+	HashKeccak256 HashFunctionID = 1
+
+	// metadataVersion is the version of the format of the stored tree
+	metadataVersion = 1
+	// metadataLength is the length of the encoded Metadata
+	metadataLength = 4 + 4 + 1 + 8 + 8 + 1
+)
+
+var (
+	// ErrInvalidMetadata is an error that indicates that the stored metadata of the tree can not be parsed
+	ErrInvalidMetadata = errors.New("invalid tree metadata")
+	// ErrUnsupportedVersion is an error that indicates that the stored tree has a format version not supported
+	ErrUnsupportedVersion = errors.New("unsupported tree format version")
+	// ErrHashFunctionMismatch is an error that indicates that the stored tree uses a different hash function
+	ErrHashFunctionMismatch = errors.New("hash function different from the one of the stored tree")
+	// ErrSharedNodesMismatch is an error that indicates that the nodes of the stored tree are stored with a different key layout
+	ErrSharedNodesMismatch = errors.New("shared nodes option different from the one of the stored tree")
+	metadataNodeValue      = HashBytes([]byte("metadata"))
+)
+
+// Metadata contains the information of how the tree stored in the database was created
+type Metadata struct {
+	Version      uint32         // format version of the stored tree
+	NumLevels    int            // number of levels of the tree
+	HashFunction HashFunctionID // hash function used by the tree
+	CreatedAt    time.Time      // creation time of the tree, with precision of seconds
+	LeafCount    uint64         // number of leafs of the current root
+	SharedNodes  bool           // if the nodes are stored without the prefix of the namespace, as in the trees without one
+}
+
+// Bytes returns the Metadata encoded in a byte array
+func (md *Metadata) Bytes() []byte {
+	b := make([]byte, metadataLength)
+	binary.LittleEndian.PutUint32(b[0:4], md.Version)
+	binary.LittleEndian.PutUint32(b[4:8], uint32(md.NumLevels))
+	b[8] = byte(md.HashFunction)
+	binary.LittleEndian.PutUint64(b[9:17], uint64(md.CreatedAt.Unix()))
+	binary.LittleEndian.PutUint64(b[17:25], md.LeafCount)
+	if md.SharedNodes {
+		b[25] = 1
+	}
+	return b
+}
+
+// parseMetadata returns the Metadata encoded in the byte array
+func parseMetadata(b []byte) (*Metadata, error) {
+	if len(b) < 4 {
+		return nil, ErrInvalidMetadata
+	}
+	md := &Metadata{Version: binary.LittleEndian.Uint32(b[0:4])}
+	if md.Version != metadataVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	if len(b) != metadataLength {
+		return nil, ErrInvalidMetadata
+	}
+	md.NumLevels = int(binary.LittleEndian.Uint32(b[4:8]))
+	md.HashFunction = HashFunctionID(b[8])
+	md.CreatedAt = time.Unix(int64(binary.LittleEndian.Uint64(b[9:17])), 0)
+	md.LeafCount = binary.LittleEndian.Uint64(b[17:25])
+	md.SharedNodes = b[25] == 1
+	return md, nil
+}
+
+// getMetadata returns the Metadata stored in the database, or leveldb.ErrNotFound if there is none
+func (mt *MerkleTree) getMetadata() (*Metadata, error) {
+	_, _, metadataBytes, err := mt.get(mt.treeKey(metadataNodeValue))
+	if err != nil {
+		return nil, err
+	}
+	return parseMetadata(metadataBytes)
+}
+
+// storeMetadata stores the Metadata of the tree in the database
+func (mt *MerkleTree) storeMetadata() error {
+	return mt.put(mt.treeKey(metadataNodeValue), metadataNodeType, 0, mt.metadata.Bytes())
+}
+
+// checkMetadata checks that the tree stored in the database, if any, was created with
+// the same parameters than the MT
+func (mt *MerkleTree) checkMetadata() error {
+	md, err := mt.getMetadata()
+	if err == leveldb.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if md.NumLevels != mt.numLevels {
+		return &NumLevelsMismatchError{mt.numLevels, md.NumLevels}
+	}
+	if md.HashFunction != mt.hasher.ID() {
+		return ErrHashFunctionMismatch
+	}
+	if md.SharedNodes != mt.sharedNodes() {
+		return ErrSharedNodesMismatch
+	}
+	mt.metadata = md
+	return nil
+}
+
+// initMetadata creates the Metadata of a tree that has none, counting the leafs of the
+// current root for the trees created before the Metadata existed
+func (mt *MerkleTree) initMetadata() error {
+	leafs, err := mt.leafs(context.Background(), mt.root)
+	if err != nil {
+		return err
+	}
+	mt.metadata = &Metadata{
+		Version:      metadataVersion,
+		NumLevels:    mt.numLevels,
+		HashFunction: mt.hasher.ID(),
+		CreatedAt:    time.Unix(time.Now().Unix(), 0),
+		LeafCount:    uint64(len(leafs)),
+		SharedNodes:  mt.sharedNodes(),
+	}
+	return mt.storeMetadata()
+}
+
+// Metadata returns the Metadata of the tree
+func (mt *MerkleTree) Metadata() Metadata {
+	return *mt.metadata
+}
@@ -0,0 +1,76 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	md := mt.Metadata()
+	assert.Equal(t, uint32(1), md.Version)
+	assert.Equal(t, 140, md.NumLevels)
+	assert.Equal(t, HashKeccak256, md.HashFunction)
+	assert.True(t, time.Since(md.CreatedAt) < time.Minute)
+	assert.Equal(t, uint64(0), md.LeafCount)
+	assert.True(t, md.SharedNodes)
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	assert.NotNil(t, mt.Add(newTestBytesLeaf("0 this is a test leaf", 15)))
+	assert.Equal(t, uint64(10), mt.Metadata().LeafCount)
+
+	// the metadata is the same when the tree is opened again
+	mt2, err := New(mt.storage, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, mt.Metadata(), mt2.Metadata())
+	assert.Equal(t, md.CreatedAt, mt2.Metadata().CreatedAt)
+
+	parsed, err := parseMetadata(md.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, md, *parsed)
+}
+
+func TestMetadataMismatch(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	_, err := New(mt.storage, 139)
+	assert.Equal(t, &NumLevelsMismatchError{139, 140}, err)
+
+	md := mt.Metadata()
+	md.HashFunction = 0
+	assert.Nil(t, mt.put(mt.treeKey(metadataNodeValue), metadataNodeType, 0, md.Bytes()))
+	_, err = New(mt.storage, 140)
+	assert.Equal(t, ErrHashFunctionMismatch, err)
+
+	md.Version = 2
+	assert.Nil(t, mt.put(mt.treeKey(metadataNodeValue), metadataNodeType, 0, md.Bytes()))
+	_, err = New(mt.storage, 140)
+	assert.Equal(t, ErrUnsupportedVersion, err)
+
+	assert.Nil(t, mt.put(mt.treeKey(metadataNodeValue), metadataNodeType, 0, []byte{1, 0, 0, 0, 140}))
+	_, err = New(mt.storage, 140)
+	assert.Equal(t, ErrInvalidMetadata, err)
+}
+
+func TestMetadataOfTreeWithoutIt(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	// a tree stored before the metadata existed
+	assert.Nil(t, mt.storage.Delete(mt.treeKey(metadataNodeValue), nil))
+	mt2, err := New(mt.storage, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, mt.Root(), mt2.Root())
+	assert.Equal(t, uint64(10), mt2.Metadata().LeafCount)
+}
@@ -0,0 +1,87 @@
+package merkletree
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// mimc7Seed is the seed of the round constants of MiMC7
+	mimc7Seed = "mimc"
+	// mimc7Rounds is the number of rounds of MiMC7
+	mimc7Rounds = 91
+)
+
+// MiMC7Hasher is the Hasher of the MiMC7 hash function over the BN254 scalar field, with
+// the constants of circomlib and its multiHash with key 0 for several elements. The leaf
+// bytes are mapped to field elements that are hashed together
+var MiMC7Hasher Hasher = mimc7Hasher{}
+
+type mimc7Hasher struct{}
+
+func (mimc7Hasher) ID() HashFunctionID {
+	return HashMiMC7
+}
+
+func (mimc7Hasher) HashBytes(b []byte) Hash {
+	return elementToHash(mimc7MultiHash(bytesToElements(b)))
+}
+
+func (mimc7Hasher) HashNode(childL, childR Hash) Hash {
+	return elementToHash(mimc7MultiHash([]*big.Int{hashToElement(childL), hashToElement(childR)}))
+}
+
+var (
+	mimc7Once      sync.Once
+	mimc7Constants []*big.Int
+)
+
+// getMiMC7Constants returns the round constants of MiMC7, generated on the first call:
+// the first one is 0, and the next ones are the chained Keccak256 of the seed
+func getMiMC7Constants() []*big.Int {
+	mimc7Once.Do(func() {
+		cts := make([]*big.Int, mimc7Rounds)
+		cts[0] = new(big.Int)
+		c := crypto.Keccak256([]byte(mimc7Seed))
+		for i := 1; i < mimc7Rounds; i++ {
+			c = crypto.Keccak256(c)
+			cts[i] = new(big.Int).SetBytes(c)
+			cts[i].Mod(cts[i], bn254Order)
+		}
+		mimc7Constants = cts
+	})
+	return mimc7Constants
+}
+
+// mimc7 returns the MiMC7 encryption of the element x with the key k
+func mimc7(x, k *big.Int) *big.Int {
+	cts := getMiMC7Constants()
+	r := new(big.Int)
+	for i := 0; i < mimc7Rounds; i++ {
+		t := new(big.Int)
+		if i == 0 {
+			t.Add(x, k)
+		} else {
+			t.Add(r, k)
+			t.Add(t, cts[i])
+		}
+		t.Mod(t, bn254Order)
+		r.Exp(t, big.NewInt(7), bn254Order)
+	}
+	r.Add(r, k)
+	return r.Mod(r, bn254Order)
+}
+
+// mimc7MultiHash returns the MiMC7 hash of the elements, chaining them in the key
+func mimc7MultiHash(elements []*big.Int) *big.Int {
+	r := new(big.Int)
+	for _, e := range elements {
+		h := mimc7(e, r)
+		r.Add(r, e)
+		r.Add(r, h)
+		r.Mod(r, bn254Order)
+	}
+	return r
+}
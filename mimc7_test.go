@@ -0,0 +1,14 @@
+package merkletree
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiMC7(t *testing.T) {
+	// test vectors of circomlib
+	h := mimc7(big.NewInt(1), big.NewInt(2))
+	assert.Equal(t, "176c6eefc3fdf8d6136002d8e6f7a885bbd1c4e3957b93ddc1ec3ae7859f1a08", h.Text(16))
+}
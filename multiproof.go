@@ -0,0 +1,307 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+)
+
+// MultiProof is a Merkle Proof of several positions of the tree, where the siblings
+// shared by the paths of the positions are only included once. The siblings are the
+// nodes out of the paths whose parent is in some path, in depth-first order from the
+// root, and the empty ones are omitted
+type MultiProof struct {
+	NonEmpty []bool // for each sibling, if it is not empty and then is in Siblings
+	Siblings []Hash // the non empty siblings
+}
+
+// fullNode is a node of the tree seen as a non optimized tree, where the final nodes
+// are expanded to the levels below them
+type fullNode struct {
+	hash      Hash
+	nodeType  byte
+	nodeBytes []byte
+	path      []bool // path of the leaf, for the final nodes
+}
+
+func (mt *MerkleTree) getFullNode(ctx context.Context, nodeHash Hash) (fullNode, error) {
+	nodeType, indexLength, nodeBytes, err := mt.getContext(ctx, nodeHash)
+	if err != nil {
+		return fullNode{}, err
+	}
+	n := fullNode{
+		hash:      nodeHash,
+		nodeType:  nodeType,
+		nodeBytes: nodeBytes,
+	}
+	if nodeType == byte(finalNodeType) {
+		n.path = getPath(mt.numLevels, mt.hasher.HashBytes(nodeBytes[:indexLength]))
+	}
+	return n, nil
+}
+
+// fullNodeChilds returns the left and right childs of the node at the depth
+func (mt *MerkleTree) fullNodeChilds(ctx context.Context, n fullNode, depth int) (fullNode, fullNode, error) {
+	empty := fullNode{hash: EmptyNodeValue, nodeType: EmptyNodeType}
+	switch n.nodeType {
+	case normalNodeType:
+		node := parseNodeBytes(n.nodeBytes)
+		l, err := mt.getFullNode(ctx, node.ChildL)
+		if err != nil {
+			return empty, empty, err
+		}
+		r, err := mt.getFullNode(ctx, node.ChildR)
+		return l, r, err
+	case finalNodeType:
+		// the leaf of the final node is at the same side in all the levels below it
+		child := n
+		child.hash = calcHashFromLeafAndLevel(mt.hasher, mt.numLevels-2-depth, n.path, mt.hasher.HashBytes(n.nodeBytes))
+		if n.path[mt.numLevels-2-depth] {
+			return empty, child, nil
+		}
+		return child, empty, nil
+	}
+	return empty, empty, nil
+}
+
+// splitPaths splits the paths by the side that they take at the depth
+func splitPaths(paths [][]bool, numLevels int, depth int) ([][]bool, [][]bool) {
+	var left, right [][]bool
+	for _, path := range paths {
+		if path[numLevels-2-depth] {
+			right = append(right, path)
+		} else {
+			left = append(left, path)
+		}
+	}
+	return left, right
+}
+
+func (mt *MerkleTree) multiProofLevel(ctx context.Context, n fullNode, depth int, paths [][]bool, mp *MultiProof) error {
+	if depth == mt.numLevels-1 {
+		return nil
+	}
+	left, right := splitPaths(paths, mt.numLevels, depth)
+	l, r, err := mt.fullNodeChilds(ctx, n, depth)
+	if err != nil {
+		return err
+	}
+	for _, side := range []struct {
+		child fullNode
+		paths [][]bool
+	}{{l, left}, {r, right}} {
+		if len(side.paths) > 0 {
+			if err := mt.multiProofLevel(ctx, side.child, depth+1, side.paths, mp); err != nil {
+				return err
+			}
+			continue
+		}
+		nonEmpty := !bytes.Equal(side.child.hash[:], EmptyNodeValue[:])
+		mp.NonEmpty = append(mp.NonEmpty, nonEmpty)
+		if nonEmpty {
+			mp.Siblings = append(mp.Siblings, side.child.hash)
+		}
+	}
+	return nil
+}
+
+// uniqueHashes returns the hashes without the repeated ones
+func uniqueHashes(hs []Hash) []Hash {
+	seen := make(map[Hash]bool)
+	var unique []Hash
+	for _, h := range hs {
+		if !seen[h] {
+			seen[h] = true
+			unique = append(unique, h)
+		}
+	}
+	return unique
+}
+
+func (mt *MerkleTree) generateMultiProof(ctx context.Context, root Hash, his []Hash) (*MultiProof, error) {
+	var paths [][]bool
+	for _, hi := range uniqueHashes(his) {
+		paths = append(paths, getPath(mt.numLevels, hi))
+	}
+	mp := &MultiProof{}
+	if len(paths) == 0 {
+		return mp, nil
+	}
+	n, err := mt.getFullNode(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	if err := mt.multiProofLevel(ctx, n, 0, paths, mp); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// GenerateMultiProof generates the MultiProof of the positions of the Hashes of the
+// Indexes (Hi) for the current root
+func (mt *MerkleTree) GenerateMultiProof(his []Hash) (*MultiProof, error) {
+	return mt.GenerateMultiProofContext(context.Background(), his)
+}
+
+// GenerateMultiProofContext generates the MultiProof as GenerateMultiProof, returning the
+// error of the context if it is done before the proof has been generated
+func (mt *MerkleTree) GenerateMultiProofContext(ctx context.Context, his []Hash) (*MultiProof, error) {
+	return mt.generateMultiProof(ctx, mt.root, his)
+}
+
+// multiProofLeaf is a position to check in a MultiProof
+type multiProofLeaf struct {
+	path []bool
+	ht   Hash
+}
+
+// multiProofChecker keeps the siblings of a MultiProof used while computing the root
+type multiProofChecker struct {
+	hasher      Hasher
+	mp          *MultiProof
+	numLevels   int
+	nonEmptyPos int
+	siblingPos  int
+}
+
+func (c *multiProofChecker) nextSibling() (Hash, bool) {
+	if c.mp == nil {
+		return EmptyNodeValue, true
+	}
+	if c.nonEmptyPos >= len(c.mp.NonEmpty) {
+		return EmptyNodeValue, false
+	}
+	nonEmpty := c.mp.NonEmpty[c.nonEmptyPos]
+	c.nonEmptyPos++
+	if !nonEmpty {
+		return EmptyNodeValue, true
+	}
+	if c.siblingPos >= len(c.mp.Siblings) {
+		return EmptyNodeValue, false
+	}
+	c.siblingPos++
+	return c.mp.Siblings[c.siblingPos-1], true
+}
+
+func (c *multiProofChecker) level(depth int, leafs []multiProofLeaf) (Hash, bool) {
+	if depth == c.numLevels-1 {
+		// different leafs can only be in the same position if all of them are the same
+		for _, leaf := range leafs[1:] {
+			if !bytes.Equal(leaf.ht[:], leafs[0].ht[:]) {
+				return EmptyNodeValue, false
+			}
+		}
+		return leafs[0].ht, true
+	}
+	var left, right []multiProofLeaf
+	for _, leaf := range leafs {
+		if leaf.path[c.numLevels-2-depth] {
+			right = append(right, leaf)
+		} else {
+			left = append(left, leaf)
+		}
+	}
+	var childs [2]Hash
+	for i, side := range [][]multiProofLeaf{left, right} {
+		var ok bool
+		if len(side) > 0 {
+			childs[i], ok = c.level(depth+1, side)
+		} else {
+			childs[i], ok = c.nextSibling()
+		}
+		if !ok {
+			return EmptyNodeValue, false
+		}
+	}
+	// if both childs are EmptyNodeValue, the parent will be EmptyNodeValue
+	if bytes.Equal(childs[0][:], EmptyNodeValue[:]) && bytes.Equal(childs[1][:], EmptyNodeValue[:]) {
+		return EmptyNodeValue, true
+	}
+	node := treeNode{
+		ChildL: childs[0],
+		ChildR: childs[1],
+	}
+	return node.Ht(c.hasher), true
+}
+
+// multiProofRoot computes the root from the leafs and the siblings of the MultiProof with
+// the Hasher, being all the siblings EmptyNodeValue when mp is nil
+func multiProofRoot(h Hasher, mp *MultiProof, his []Hash, hts []Hash, numLevels int) (Hash, bool) {
+	if numLevels < minNumLevels || numLevels > maxNumLevels {
+		return EmptyNodeValue, false
+	}
+	if len(his) != len(hts) || len(his) == 0 {
+		return EmptyNodeValue, false
+	}
+	leafs := make(map[Hash]Hash)
+	var unique []multiProofLeaf
+	for i, hi := range his {
+		if ht, ok := leafs[hi]; ok {
+			if !bytes.Equal(ht[:], hts[i][:]) {
+				return EmptyNodeValue, false
+			}
+			continue
+		}
+		leafs[hi] = hts[i]
+		unique = append(unique, multiProofLeaf{getPath(numLevels, hi), hts[i]})
+	}
+	c := multiProofChecker{hasher: h, mp: mp, numLevels: numLevels}
+	nodeHash, ok := c.level(0, unique)
+	if !ok || (mp != nil && (c.nonEmptyPos != len(mp.NonEmpty) || c.siblingPos != len(mp.Siblings))) {
+		return EmptyNodeValue, false
+	}
+	return nodeHash, true
+}
+
+// CheckMultiProof validates the MultiProof for the root, where hts contains the
+// hash of the value (Ht) in the position of each Hash of the Index (Hi) of his,
+// being EmptyNodeValue for the empty positions
+func CheckMultiProof(root Hash, mp *MultiProof, his []Hash, hts []Hash, numLevels int) bool {
+	return CheckMultiProofWithHasher(Keccak256Hasher, root, mp, his, hts, numLevels)
+}
+
+// CheckMultiProofWithHasher validates the MultiProof for the root of a tree with the Hasher
+func CheckMultiProofWithHasher(h Hasher, root Hash, mp *MultiProof, his []Hash, hts []Hash, numLevels int) bool {
+	nodeHash, ok := multiProofRoot(h, mp, his, hts, numLevels)
+	return ok && bytes.Equal(nodeHash[:], root[:])
+}
+
+// Bytes returns the MultiProof encoded in a byte array
+func (mp *MultiProof) Bytes() []byte {
+	nonEmpty := make([]byte, (len(mp.NonEmpty)+7)/8)
+	for i, ne := range mp.NonEmpty {
+		if ne {
+			setbitmap(nonEmpty, uint(i))
+		}
+	}
+	var b []byte
+	b = append(b, Uint32ToBytes(uint32(len(mp.NonEmpty)))...)
+	b = append(b, nonEmpty...)
+	for _, sibling := range mp.Siblings {
+		b = append(b, sibling[:]...)
+	}
+	return b
+}
+
+// ParseMultiProof returns the MultiProof encoded in the byte array
+func ParseMultiProof(b []byte) (*MultiProof, error) {
+	if len(b) < 4 {
+		return nil, ErrInvalidProof
+	}
+	n := uint64(BytesToUint32(b[:4]))
+	bitmapLen := (n + 7) / 8
+	hashLen := uint64(len(EmptyNodeValue))
+	if uint64(len(b)-4) < bitmapLen || (uint64(len(b)-4)-bitmapLen)%hashLen != 0 {
+		return nil, ErrInvalidProof
+	}
+	nonEmpty := b[4 : 4+bitmapLen]
+	mp := &MultiProof{NonEmpty: make([]bool, n)}
+	for i := range mp.NonEmpty {
+		mp.NonEmpty[i] = testbitmap(nonEmpty, uint(i))
+	}
+	for i := 4 + bitmapLen; i < uint64(len(b)); i += hashLen {
+		var sibling Hash
+		copy(sibling[:], b[i:i+hashLen])
+		mp.Siblings = append(mp.Siblings, sibling)
+	}
+	return mp, nil
+}
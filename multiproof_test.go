@@ -0,0 +1,97 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiProof(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	var his, hts []Hash
+	proofsLength := 0
+	for i := 0; i < 100; i++ {
+		leaf := newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)
+		assert.Nil(t, mt.Add(leaf))
+		if i%5 == 0 {
+			his = append(his, leaf.Hi())
+			hts = append(hts, HashBytes(leaf.Bytes()))
+		}
+	}
+	// positions that are empty
+	for i := 0; i < 5; i++ {
+		his = append(his, newTestBytesLeaf(strconv.Itoa(i)+" this is not in the tree", 15).Hi())
+		hts = append(hts, EmptyNodeValue)
+	}
+	for _, hi := range his {
+		proof, err := mt.GenerateProof(hi)
+		assert.Nil(t, err)
+		proofsLength += len(proof)
+	}
+
+	mp, err := mt.GenerateMultiProof(his)
+	assert.Nil(t, err)
+	assert.True(t, CheckMultiProof(mt.Root(), mp, his, hts, mt.NumLevels()))
+	assert.True(t, len(mp.Bytes()) < proofsLength)
+
+	parsed, err := ParseMultiProof(mp.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, mp, parsed)
+	assert.True(t, CheckMultiProof(mt.Root(), parsed, his, hts, mt.NumLevels()))
+
+	// wrong values
+	hts[3] = EmptyNodeValue
+	assert.False(t, CheckMultiProof(mt.Root(), mp, his, hts, mt.NumLevels()))
+	hts[3] = hts[4]
+	assert.False(t, CheckMultiProof(mt.Root(), mp, his, hts, mt.NumLevels()))
+	// proof of a different set of positions
+	assert.False(t, CheckMultiProof(mt.Root(), mp, his[1:], hts[1:], mt.NumLevels()))
+	// wrong sibling
+	mp.Siblings[0][0]++
+	assert.False(t, CheckMultiProof(mt.Root(), mp, his, hts, mt.NumLevels()))
+}
+
+func TestMultiProofSingleLeaf(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	leaf := newTestBytesLeaf("this is a test leaf", 15)
+	leaf2 := newTestBytesLeaf("this is a second test leaf", 15)
+	leaf3 := newTestBytesLeaf("this is a third test leaf", 15)
+
+	// empty tree
+	mp, err := mt.GenerateMultiProof([]Hash{leaf.Hi()})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(mp.Siblings))
+	assert.True(t, CheckMultiProof(mt.Root(), mp, []Hash{leaf.Hi()}, []Hash{EmptyNodeValue}, mt.NumLevels()))
+
+	assert.Nil(t, mt.Add(leaf))
+	assert.Nil(t, mt.Add(leaf2))
+
+	// same siblings than the proof of the test vector, but from the root
+	mp, err = mt.GenerateMultiProof([]Hash{leaf3.Hi(), leaf3.Hi()})
+	assert.Nil(t, err)
+	assert.Equal(t, "0xfd8e1a60cdb23c0c7b2cf8462c99fafd905054dccb0ed75e7c8a7d6806749b6b", mp.Siblings[0].Hex())
+	assert.Equal(t, "0x89741fa23da77c259781ad8f4331a5a7d793eef1db7e5200ddfc8e5f5ca7ce2b", mp.Siblings[1].Hex())
+	assert.True(t, CheckMultiProof(mt.Root(), mp, []Hash{leaf3.Hi()}, []Hash{EmptyNodeValue}, mt.NumLevels()))
+
+	// no siblings needed to proof all the leafs of the tree
+	his := []Hash{leaf.Hi(), leaf2.Hi()}
+	hts := []Hash{HashBytes(leaf.Bytes()), HashBytes(leaf2.Bytes())}
+	mp, err = mt.GenerateMultiProof(his)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(mp.Siblings))
+	assert.True(t, CheckMultiProof(mt.Root(), mp, his, hts, mt.NumLevels()))
+}
+
+func TestParseMultiProofErrors(t *testing.T) {
+	_, err := ParseMultiProof([]byte{1, 0})
+	assert.Equal(t, ErrInvalidProof, err)
+	_, err = ParseMultiProof([]byte{9, 0, 0, 0, 1})
+	assert.Equal(t, ErrInvalidProof, err)
+	_, err = ParseMultiProof([]byte{1, 0, 0, 0, 1, 2})
+	assert.Equal(t, ErrInvalidProof, err)
+}
@@ -0,0 +1,94 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countKeys returns the number of keys of the database of the MT
+func countKeys(mt *MerkleTree) int {
+	iter := mt.storage.NewIterator(nil, nil)
+	defer iter.Release()
+	n := 0
+	for iter.Next() {
+		n++
+	}
+	return n
+}
+
+func TestNamespaces(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	alice, err := NewWithOptions(mt.storage, 140, Options{Namespace: "alice"})
+	assert.Nil(t, err)
+	bob, err := NewWithOptions(mt.storage, 4, Options{Namespace: "bob"})
+	assert.Nil(t, err)
+	assert.Equal(t, EmptyNodeValue, alice.Root())
+	assert.Equal(t, 4, bob.Metadata().NumLevels)
+
+	assert.Nil(t, mt.Add(newTestBytesLeaf("this is a test leaf", 15)))
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, alice.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	assert.Nil(t, bob.Add(newTestBytesLeaf("this is a second test leaf", 15)))
+
+	// each tree has its own root and metadata
+	mt, err = New(mt.storage, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, "0xb4fdf8a653198f0e179ccb3af7e4fc09d76247f479d6cfc95cd92d6fda589f27", mt.Root().Hex())
+	assert.Equal(t, uint64(1), mt.Metadata().LeafCount)
+	alice2, err := NewWithOptions(mt.storage, 140, Options{Namespace: "alice"})
+	assert.Nil(t, err)
+	assert.Equal(t, alice.Root(), alice2.Root())
+	assert.Equal(t, uint64(10), alice2.Metadata().LeafCount)
+	_, err = NewWithOptions(mt.storage, 140, Options{Namespace: "bob"})
+	assert.Equal(t, &NumLevelsMismatchError{140, 4}, err)
+
+	// the nodes of a namespace are not in the others
+	_, _, _, err = mt.Get(alice.Root())
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(alice2.Verify(alice2.Root())))
+}
+
+func TestNamespacesSharedNodes(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	alice, err := NewWithOptions(mt.storage, 140, Options{Namespace: "alice", SharedNodes: true})
+	assert.Nil(t, err)
+	bob, err := NewWithOptions(mt.storage, 140, Options{Namespace: "bob", SharedNodes: true})
+	assert.Nil(t, err)
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, alice.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	keys := countKeys(mt)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, bob.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	// bob only updates its root and metadata, that are already stored
+	assert.Equal(t, keys, countKeys(mt))
+	assert.Equal(t, alice.Root(), bob.Root())
+
+	// the nodes are readable from the trees that share them
+	_, _, _, err = mt.Get(alice.Root())
+	assert.Nil(t, err)
+	assert.Nil(t, bob.Add(newTestBytesLeaf("this is a test leaf", 15)))
+	assert.NotEqual(t, alice.Root(), bob.Root())
+	assert.True(t, alice.Metadata().SharedNodes)
+
+	// the stored trees can only be opened with the same key layout of the nodes
+	_, err = NewWithOptions(mt.storage, 140, Options{Namespace: "alice"})
+	assert.Equal(t, ErrSharedNodesMismatch, err)
+	carol, err := NewWithOptions(mt.storage, 140, Options{Namespace: "carol"})
+	assert.Nil(t, err)
+	assert.False(t, carol.Metadata().SharedNodes)
+	_, err = NewWithOptions(mt.storage, 140, Options{Namespace: "carol", SharedNodes: true})
+	assert.Equal(t, ErrSharedNodesMismatch, err)
+	// the nodes of the trees without namespace are always shared
+	_, err = NewWithOptions(mt.storage, 140, Options{SharedNodes: true})
+	assert.Nil(t, err)
+}
@@ -15,10 +15,9 @@ func (n *treeNode) Bytes() (b []byte) {
 	return b
 }
 
-// Ht returns the hash of the full node
-func (n *treeNode) Ht() Hash {
-	h := HashBytes(n.Bytes())
-	return h
+// Ht returns the hash of the full node with the Hasher
+func (n *treeNode) Ht(h Hasher) Hash {
+	return h.HashNode(n.ChildL, n.ChildR)
 }
 
 // ParseNodeBytes returns a Node struct from an array of bytes
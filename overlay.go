@@ -0,0 +1,62 @@
+package merkletree
+
+import "errors"
+
+// ErrOverlayConflict is an error that indicates that the root of the merkletree has changed since the overlay was created
+var ErrOverlayConflict = errors.New("merkletree root changed since the overlay was created")
+
+// Overlay is a hypothetical version of a MerkleTree, whose new nodes are kept in memory
+// over the ones of the database. It is used to know the root and the proofs that the
+// tree would have after some changes, without writing them
+type Overlay struct {
+	mt *MerkleTree // tree under the overlay
+	tx *Tx         // transaction that stages the new nodes, never committed
+}
+
+// NewOverlay returns an Overlay over the current root of the MT
+func (mt *MerkleTree) NewOverlay() *Overlay {
+	return &Overlay{
+		mt: mt,
+		tx: mt.Begin(),
+	}
+}
+
+// Add adds the leaf to the overlay
+func (o *Overlay) Add(v Value) error {
+	return o.tx.Add(v)
+}
+
+// Root returns the hypothetical root of the overlay
+func (o *Overlay) Root() Hash {
+	return o.tx.Root()
+}
+
+// GetValueInPos returns the value in the position of the Hi in the overlay
+func (o *Overlay) GetValueInPos(hi Hash) ([]byte, error) {
+	return o.tx.GetValueInPos(hi)
+}
+
+// GenerateProof generates the Merkle Proof of the Hi for the hypothetical root of the overlay
+func (o *Overlay) GenerateProof(hi Hash) ([]byte, error) {
+	return o.tx.GenerateProof(hi)
+}
+
+// Promote writes the nodes of the overlay to the database in a single batch, making its
+// root the one of the MT, and the overlay continues over the new root. Returns
+// ErrOverlayConflict if the root of the MT has changed since the overlay was created
+func (o *Overlay) Promote() error {
+	err := o.tx.write()
+	if err == ErrTxConflict {
+		return ErrOverlayConflict
+	}
+	if err != nil {
+		return err
+	}
+	o.Discard()
+	return nil
+}
+
+// Discard throws away the nodes of the overlay, that continues over the current root of the MT
+func (o *Overlay) Discard() {
+	o.tx = o.mt.Begin()
+}
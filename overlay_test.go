@@ -0,0 +1,76 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverlay(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+	mt2 := newTestingMerkle(t, 140)
+	defer mt2.storage.Close()
+
+	leafs := addTestLeafs(t, mt, 10)
+	for _, leaf := range leafs {
+		assert.Nil(t, mt2.Add(leaf))
+	}
+	root := mt.Root()
+
+	o := mt.NewOverlay()
+	for i := 0; i < 5; i++ {
+		leaf := NewLeaf([]byte(strconv.Itoa(i)+" overlay index"), []byte("data"))
+		assert.Nil(t, o.Add(leaf))
+		assert.Nil(t, mt2.Add(leaf))
+	}
+	// the overlay has the root that the tree would have, without changing it
+	assert.Equal(t, mt2.Root(), o.Root())
+	assert.Equal(t, root, mt.Root())
+	hi := HashBytes([]byte("3 overlay index"))
+	proof, err := o.GenerateProof(hi)
+	assert.Nil(t, err)
+	expected, err := mt2.GenerateProof(hi)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, proof)
+	value, err := o.GetValueInPos(hi)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("3 overlay indexdata"), value)
+	value, err = mt.GetValueInPos(hi)
+	assert.Nil(t, err)
+	assert.Equal(t, EmptyNodeValue[:], value)
+
+	// nothing is written until the overlay is promoted
+	_, _, _, err = mt.Get(o.Root())
+	assert.NotNil(t, err)
+	assert.Nil(t, o.Promote())
+	assert.Equal(t, mt2.Root(), mt.Root())
+	assert.Equal(t, mt.Root(), o.Root())
+	assert.Equal(t, 0, len(mt.Verify(mt.Root())))
+	assert.Equal(t, mt2.Metadata().LeafCount, mt.Metadata().LeafCount)
+	mt3, err := New(mt.storage, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, mt2.Root(), mt3.Root())
+}
+
+func TestOverlayDiscard(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	addTestLeafs(t, mt, 10)
+	root := mt.Root()
+
+	o := mt.NewOverlay()
+	assert.Nil(t, o.Add(NewLeaf([]byte("overlay index"), []byte("data"))))
+	o.Discard()
+	assert.Equal(t, root, o.Root())
+	assert.Equal(t, root, mt.Root())
+
+	// the overlay can not be promoted over a root that has changed
+	assert.Nil(t, o.Add(NewLeaf([]byte("overlay index"), []byte("data"))))
+	assert.Nil(t, mt.Add(NewLeaf([]byte("other index"), []byte("data"))))
+	assert.Equal(t, ErrOverlayConflict, o.Promote())
+	o.Discard()
+	assert.Equal(t, mt.Root(), o.Root())
+}
@@ -0,0 +1,193 @@
+package merkletree
+
+import (
+	"math/big"
+	"sync"
+)
+
+const (
+	// poseidonWidth is the number of field elements of the state, the capacity and two inputs
+	poseidonWidth = 3
+	// poseidonFullRounds is the number of rounds with the S-box in all the elements
+	poseidonFullRounds = 8
+	// poseidonPartialRounds is the number of rounds with the S-box only in the first element
+	poseidonPartialRounds = 57
+	// poseidonFieldBits is the number of bits of the field elements
+	poseidonFieldBits = 254
+)
+
+// PoseidonHasher is the Hasher of the Poseidon hash function over the BN254 scalar field,
+// with the parameters and constants of circomlib for two inputs. The leaf bytes are
+// mapped to field elements that are hashed in a chain
+var PoseidonHasher Hasher = poseidonHasher{}
+
+type poseidonHasher struct{}
+
+func (poseidonHasher) ID() HashFunctionID {
+	return HashPoseidon
+}
+
+func (poseidonHasher) HashBytes(b []byte) Hash {
+	elements := bytesToElements(b)
+	h := elements[0]
+	for _, e := range elements[1:] {
+		h = poseidon(h, e)
+	}
+	return elementToHash(h)
+}
+
+func (poseidonHasher) HashNode(childL, childR Hash) Hash {
+	return elementToHash(poseidon(hashToElement(childL), hashToElement(childR)))
+}
+
+// poseidonParams are the round constants and the MDS matrix of the permutation
+type poseidonParams struct {
+	c [][]*big.Int // round constants of each round
+	m [][]*big.Int // MDS matrix
+}
+
+var (
+	poseidonOnce      sync.Once
+	poseidonConstants *poseidonParams
+)
+
+// grainLFSR is the Grain LFSR used by the reference implementation of Poseidon to
+// generate the round constants and the MDS matrix from the parameters
+type grainLFSR struct {
+	state [80]bool
+}
+
+func newGrainLFSR() *grainLFSR {
+	g := &grainLFSR{}
+	pos := 0
+	appendBits := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			g.state[pos] = (v>>uint(i))&1 == 1
+			pos++
+		}
+	}
+	appendBits(1, 2) // prime field
+	appendBits(0, 4) // S-box x^alpha
+	appendBits(poseidonFieldBits, 12)
+	appendBits(poseidonWidth, 12)
+	appendBits(poseidonFullRounds, 10)
+	appendBits(poseidonPartialRounds, 10)
+	appendBits((1<<30)-1, 30)
+	for i := 0; i < 160; i++ {
+		g.update()
+	}
+	return g
+}
+
+// update shifts the LFSR, returning the new bit
+func (g *grainLFSR) update() bool {
+	bit := g.state[62] != g.state[51]
+	bit = bit != g.state[38]
+	bit = bit != g.state[23]
+	bit = bit != g.state[13]
+	bit = bit != g.state[0]
+	copy(g.state[:], g.state[1:])
+	g.state[79] = bit
+	return bit
+}
+
+// nextBit returns the next output bit, discarding the pairs of bits whose first one is 0
+func (g *grainLFSR) nextBit() bool {
+	for !g.update() {
+		g.update()
+	}
+	return g.update()
+}
+
+// nextInt returns the number of the next n output bits, from the most significant one
+func (g *grainLFSR) nextInt(n int) *big.Int {
+	r := new(big.Int)
+	for i := 0; i < n; i++ {
+		r.Lsh(r, 1)
+		if g.nextBit() {
+			r.SetBit(r, 0, 1)
+		}
+	}
+	return r
+}
+
+// nextElement returns the next field element, discarding the numbers out of the field
+func (g *grainLFSR) nextElement() *big.Int {
+	for {
+		r := g.nextInt(poseidonFieldBits)
+		if r.Cmp(bn254Order) < 0 {
+			return r
+		}
+	}
+}
+
+// getPoseidonParams returns the parameters of Poseidon, generated on the first call
+func getPoseidonParams() *poseidonParams {
+	poseidonOnce.Do(func() {
+		g := newGrainLFSR()
+		p := &poseidonParams{}
+		for r := 0; r < poseidonFullRounds+poseidonPartialRounds; r++ {
+			row := make([]*big.Int, poseidonWidth)
+			for i := range row {
+				row[i] = g.nextElement()
+			}
+			p.c = append(p.c, row)
+		}
+		// the MDS matrix is the Cauchy matrix 1/(x_i + y_j), with the x and y elements
+		// reduced by the field modulus
+		xy := make([]*big.Int, 2*poseidonWidth)
+		for i := range xy {
+			xy[i] = g.nextInt(poseidonFieldBits)
+			xy[i].Mod(xy[i], bn254Order)
+		}
+		p.m = make([][]*big.Int, poseidonWidth)
+		for i := 0; i < poseidonWidth; i++ {
+			p.m[i] = make([]*big.Int, poseidonWidth)
+			for j := 0; j < poseidonWidth; j++ {
+				sum := new(big.Int).Add(xy[i], xy[poseidonWidth+j])
+				p.m[i][j] = sum.ModInverse(sum.Mod(sum, bn254Order), bn254Order)
+			}
+		}
+		poseidonConstants = p
+	})
+	return poseidonConstants
+}
+
+// pow5 returns x^5 in the field
+func pow5(x *big.Int) *big.Int {
+	r := new(big.Int).Mul(x, x)
+	r.Mod(r, bn254Order)
+	r.Mul(r, r)
+	r.Mod(r, bn254Order)
+	r.Mul(r, x)
+	return r.Mod(r, bn254Order)
+}
+
+// poseidon returns the Poseidon hash of the two field elements
+func poseidon(a, b *big.Int) *big.Int {
+	p := getPoseidonParams()
+	state := []*big.Int{new(big.Int), new(big.Int).Set(a), new(big.Int).Set(b)}
+	for r := 0; r < poseidonFullRounds+poseidonPartialRounds; r++ {
+		for i := range state {
+			state[i].Add(state[i], p.c[r][i])
+			state[i].Mod(state[i], bn254Order)
+		}
+		if r < poseidonFullRounds/2 || r >= poseidonFullRounds/2+poseidonPartialRounds {
+			for i := range state {
+				state[i] = pow5(state[i])
+			}
+		} else {
+			state[0] = pow5(state[0])
+		}
+		mixed := make([]*big.Int, poseidonWidth)
+		for i := range mixed {
+			mixed[i] = new(big.Int)
+			for j := range state {
+				mixed[i].Add(mixed[i], new(big.Int).Mul(p.m[i][j], state[j]))
+			}
+			mixed[i].Mod(mixed[i], bn254Order)
+		}
+		state = mixed
+	}
+	return state[0]
+}
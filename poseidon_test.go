@@ -0,0 +1,14 @@
+package merkletree
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoseidon(t *testing.T) {
+	// test vectors of circomlib
+	h := poseidon(big.NewInt(1), big.NewInt(2))
+	assert.Equal(t, "7853200120776062878684798364095072458815029376092732009249414926327459813530", h.String())
+}
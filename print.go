@@ -26,8 +26,8 @@ func (mt *MerkleTree) printLevel(parent Hash, iLevel int, maxLevel int) {
 		color.Green("value")
 	} else if nodeType == byte(finalNodeType) { //typ==FINAL_NODE
 		fmt.Print("[FinalTree]:")
-		color.Cyan("final tree node: " + HashBytes(nodeBytes).Hex())
-		_, _, leafNodeBytes, err := mt.Get(HashBytes(nodeBytes))
+		color.Cyan("final tree node: " + mt.hasher.HashBytes(nodeBytes).Hex())
+		_, _, leafNodeBytes, err := mt.Get(mt.hasher.HashBytes(nodeBytes))
 		if err != nil {
 			color.Red(err.Error())
 		}
@@ -0,0 +1,39 @@
+package merkletree
+
+import "context"
+
+// Receipt is the result of adding a leaf to the MT, with the proof of its inclusion
+type Receipt struct {
+	OldRoot Hash   // root of the MT before adding the leaf
+	NewRoot Hash   // root of the MT with the leaf
+	Proof   []byte // Merkle Proof of the leaf for the NewRoot, in the GenerateProof format
+}
+
+// AddWithReceipt adds the leaf to the MT as Add, and returns the Receipt with its Merkle
+// Proof, built from the siblings of the path of the insertion
+func (mt *MerkleTree) AddWithReceipt(v Value) (*Receipt, error) {
+	oldRoot := mt.root
+	siblings, err := mt.add(context.Background(), v)
+	if err != nil {
+		return nil, err
+	}
+	return &Receipt{
+		OldRoot: oldRoot,
+		NewRoot: mt.root,
+		Proof:   compressProof(siblings),
+	}, nil
+}
+
+// Check validates the Merkle Proof of the Receipt for the leaf and the NewRoot
+func (r *Receipt) Check(v Value, numLevels int) bool {
+	return r.CheckWithHasher(Keccak256Hasher, v, numLevels)
+}
+
+// CheckWithHasher validates the Merkle Proof of the Receipt of a tree with the Hasher
+func (r *Receipt) CheckWithHasher(h Hasher, v Value, numLevels int) bool {
+	if validateValue(h, v) != nil {
+		return false
+	}
+	hi := h.HashBytes(v.Bytes()[:v.IndexLength()])
+	return CheckProofWithHasher(h, r.NewRoot, r.Proof, hi, h.HashBytes(v.Bytes()), numLevels)
+}
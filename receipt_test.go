@@ -0,0 +1,50 @@
+package merkletree
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddWithReceipt(t *testing.T) {
+	for _, numLevels := range []int{140, 8} {
+		mt := newTestingMerkle(t, numLevels)
+		defer mt.storage.Close()
+
+		for i := 0; i < 40; i++ {
+			leaf := NewLeaf([]byte(strconv.Itoa(i)+" index"), []byte("data"))
+			oldRoot := mt.Root()
+			r, err := mt.AddWithReceipt(leaf)
+			if err != nil {
+				// in the small tree some leafs have the path of another one
+				assert.True(t, errors.Is(err, ErrPathCollision))
+				assert.Equal(t, oldRoot, mt.Root())
+				continue
+			}
+			assert.Equal(t, oldRoot, r.OldRoot)
+			assert.Equal(t, mt.Root(), r.NewRoot)
+			assert.True(t, r.Check(leaf, numLevels))
+			// the proof is the same than the generated after the insertion
+			proof, err := mt.GenerateProof(HashBytes(leaf.Index()))
+			assert.Nil(t, err)
+			assert.Equal(t, proof, r.Proof)
+		}
+	}
+}
+
+func TestReceiptCheckWithHasher(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+	tree, err := NewWithOptions(mt.storage, 140, Options{Namespace: "poseidon", Hasher: PoseidonHasher})
+	assert.Nil(t, err)
+
+	leaf := NewLeaf([]byte("index"), []byte("data"))
+	r, err := tree.AddWithReceipt(leaf)
+	assert.Nil(t, err)
+	// the Receipt rebuilt from its fields is checked with the Hasher of the tree
+	received := Receipt{r.OldRoot, r.NewRoot, r.Proof}
+	assert.True(t, received.CheckWithHasher(PoseidonHasher, leaf, 140))
+	assert.False(t, received.Check(leaf, 140))
+}
@@ -0,0 +1,265 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// ReferenceTree is a non optimized sparse Merkle Tree in memory, without final nodes:
+// each leaf is at the bottom level, and every node of its path up to the root is stored.
+// It has the same roots and proofs than the MerkleTree, and is used to check it
+type ReferenceTree struct {
+	numLevels int
+	hasher    Hasher
+	nodes     map[referenceKey]Hash // non empty nodes, by depth and path
+	leafs     map[Hash]Leaf         // leafs by their path
+}
+
+// referenceKey identifies a node of the ReferenceTree by its depth, being 0 the root, and
+// the bits of the path from the root to the node
+type referenceKey struct {
+	depth int
+	path  Hash
+}
+
+// pathPrefix returns the Hash with only the bits of the path of the Hi until the depth
+func pathPrefix(hi Hash, depth int) Hash {
+	var prefix Hash
+	for bitno := 0; bitno < depth; bitno++ {
+		if testbitmap(hi[:], uint(bitno)) {
+			setbitmap(prefix[:], uint(bitno))
+		}
+	}
+	return prefix
+}
+
+// NewReferenceTree returns an empty ReferenceTree with the Hasher, or the Keccak256Hasher
+// when it is nil
+func NewReferenceTree(numLevels int, hasher Hasher) (*ReferenceTree, error) {
+	if numLevels < minNumLevels || numLevels > maxNumLevels {
+		return nil, ErrInvalidNumLevels
+	}
+	if hasher == nil {
+		hasher = Keccak256Hasher
+	}
+	return &ReferenceTree{
+		numLevels: numLevels,
+		hasher:    hasher,
+		nodes:     make(map[referenceKey]Hash),
+		leafs:     make(map[Hash]Leaf),
+	}, nil
+}
+
+// node returns the hash of the node of the path at the depth, the EmptyNodeValue if it is not stored
+func (rt *ReferenceTree) node(depth int, path Hash) Hash {
+	if nodeHash, ok := rt.nodes[referenceKey{depth, path}]; ok {
+		return nodeHash
+	}
+	return EmptyNodeValue
+}
+
+// sibling returns the hash of the sibling of the node of the Hi at the depth
+func (rt *ReferenceTree) sibling(hi Hash, depth int) Hash {
+	path := pathPrefix(hi, depth)
+	path[len(path)-1-(depth-1)/8] ^= 1 << (uint(depth-1) % 8)
+	return rt.node(depth, path)
+}
+
+// validate returns the sentinel error that the Add of the MerkleTree matches when the
+// value can not be a leaf, written apart from the checks of the MerkleTree
+func (rt *ReferenceTree) validate(v Value) error {
+	b := v.Bytes()
+	switch {
+	case v.IndexLength() == 0:
+		return ErrEmptyIndex
+	case int64(v.IndexLength()) > int64(len(b)):
+		return ErrIndexLengthOutOfRange
+	case len(b) == len(EmptyNodeValue) && bytes.Count(b, []byte{0}) == len(b):
+		return ErrEmptyValue
+	case rt.hasher.HashBytes(b) == EmptyNodeValue:
+		return ErrEmptyValue
+	}
+	return nil
+}
+
+// Add adds the leaf to the ReferenceTree, returning the sentinel errors that the errors
+// of the Add of the MerkleTree match when the value is invalid or its position is used
+func (rt *ReferenceTree) Add(v Value) error {
+	if err := rt.validate(v); err != nil {
+		return err
+	}
+	hi := rt.hasher.HashBytes(v.Bytes()[:v.IndexLength()])
+	bottom := pathPrefix(hi, rt.numLevels-1)
+	if leaf, ok := rt.leafs[bottom]; ok {
+		if bytes.Equal(leaf.Index(), v.Bytes()[:v.IndexLength()]) {
+			return ErrNodeAlreadyExists
+		}
+		return ErrPathCollision
+	}
+	rt.leafs[bottom] = Leaf{
+		data:        append([]byte{}, v.Bytes()...),
+		indexLength: v.IndexLength(),
+	}
+
+	nodeHash := rt.hasher.HashBytes(v.Bytes())
+	rt.nodes[referenceKey{rt.numLevels - 1, bottom}] = nodeHash
+	for depth := rt.numLevels - 2; depth >= 0; depth-- {
+		sibling := rt.sibling(hi, depth+1)
+		// the leaf is not empty, so none of the nodes of its path is empty
+		if testbitmap(hi[:], uint(depth)) {
+			nodeHash = rt.hasher.HashNode(sibling, nodeHash)
+		} else {
+			nodeHash = rt.hasher.HashNode(nodeHash, sibling)
+		}
+		rt.nodes[referenceKey{depth, pathPrefix(hi, depth)}] = nodeHash
+	}
+	return nil
+}
+
+// Root returns the root of the ReferenceTree
+func (rt *ReferenceTree) Root() Hash {
+	return rt.node(0, Hash{})
+}
+
+// GetValueInPos returns the bytes of the leaf in the position of the Hi, or the
+// EmptyNodeValue if it is empty
+func (rt *ReferenceTree) GetValueInPos(hi Hash) []byte {
+	leaf, ok := rt.leafs[pathPrefix(hi, rt.numLevels-1)]
+	if !ok {
+		return EmptyNodeValue[:]
+	}
+	return leaf.Bytes()
+}
+
+// GenerateProof generates the Merkle Proof of the position of the Hi, in the format of the
+// GenerateProof of the MerkleTree: a bitmap with a bit for each non empty sibling, followed
+// by the non empty siblings from the bottom to the root
+func (rt *ReferenceTree) GenerateProof(hi Hash) []byte {
+	var bitmap Hash
+	var siblings []byte
+	for depth := rt.numLevels - 1; depth > 0; depth-- {
+		sibling := rt.sibling(hi, depth)
+		if sibling != EmptyNodeValue {
+			bitmap[len(bitmap)-1-(depth-1)/8] |= 1 << (uint(depth-1) % 8)
+			siblings = append(siblings, sibling[:]...)
+		}
+	}
+	return append(bitmap[:], siblings...)
+}
+
+// DivergenceError is an error that indicates that the MerkleTree and the ReferenceTree
+// differ after adding the same leafs
+type DivergenceError struct {
+	Step   int    // number of values added to the trees when they differ
+	Reason string // what differs between the trees
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("divergence from the reference tree at step %d: %s", e.Step, e.Reason)
+}
+
+// CheckAgainstReference adds the values to an Overlay of the MT and to a ReferenceTree
+// with its leafs, and returns a DivergenceError if the results of Add, the roots or the
+// proofs of the values differ between them. The values that can not be added are
+// expected to fail in both. The MT is not changed, as the Overlay is never promoted
+func (mt *MerkleTree) CheckAgainstReference(values []Value) error {
+	rt, err := NewReferenceTree(mt.numLevels, mt.hasher)
+	if err != nil {
+		return err
+	}
+	leafs, err := mt.leafs(context.Background(), mt.root)
+	if err != nil {
+		return err
+	}
+	for _, leaf := range leafs {
+		if err := rt.Add(leaf); err != nil {
+			return &DivergenceError{0, fmt.Sprintf("leaf %x can not be added: %v", leaf.Bytes(), err)}
+		}
+	}
+	o := mt.NewOverlay()
+	if err := compareReference(o, rt, 0, nil); err != nil {
+		return err
+	}
+
+	for i, v := range values {
+		oErr := o.Add(v)
+		var storageErr *StorageError
+		if errors.As(oErr, &storageErr) {
+			return oErr
+		}
+		rtErr := rt.Add(v)
+		if (oErr == nil) != (rtErr == nil) || (rtErr != nil && !errors.Is(oErr, rtErr)) {
+			return &DivergenceError{i + 1, fmt.Sprintf("add of %x returns %v, the reference %v", v.Bytes(), oErr, rtErr)}
+		}
+		var hi []Hash
+		if rt.validate(v) == nil {
+			hi = append(hi, mt.hasher.HashBytes(v.Bytes()[:v.IndexLength()]))
+		}
+		if err := compareReference(o, rt, i+1, hi); err != nil {
+			return err
+		}
+	}
+
+	// at the end, the positions of all the values are compared
+	var his []Hash
+	for _, leaf := range leafs {
+		his = append(his, mt.hasher.HashBytes(leaf.Index()))
+	}
+	for _, v := range values {
+		if rt.validate(v) == nil {
+			his = append(his, mt.hasher.HashBytes(v.Bytes()[:v.IndexLength()]))
+		}
+	}
+	return compareReference(o, rt, len(values), his)
+}
+
+// CheckRandomAgainstReference runs CheckAgainstReference with a sequence of n random
+// leafs generated from the seed, where some leafs are repeated, so the same seed always
+// checks the same sequence
+func (mt *MerkleTree) CheckRandomAgainstReference(seed int64, n int) error {
+	return mt.CheckAgainstReference(randomValues(rand.New(rand.NewSource(seed)), n))
+}
+
+// randomValues returns n random leafs, with a tenth of them repeating a previous one
+func randomValues(r *rand.Rand, n int) []Value {
+	var values []Value
+	for i := 0; i < n; i++ {
+		if i > 0 && r.Intn(10) == 0 {
+			values = append(values, values[r.Intn(i)])
+			continue
+		}
+		b := make([]byte, 1+r.Intn(40))
+		r.Read(b)
+		indexLength := 1 + r.Intn(len(b))
+		values = append(values, NewLeaf(b[:indexLength], b[indexLength:]))
+	}
+	return values
+}
+
+// compareReference returns a DivergenceError if the root of the Overlay, or the values and
+// the proofs of the positions of the his differ from the ones of the ReferenceTree
+func compareReference(o *Overlay, rt *ReferenceTree, step int, his []Hash) error {
+	if o.Root() != rt.Root() {
+		return &DivergenceError{step, fmt.Sprintf("root %s, the reference %s", o.Root().Hex(), rt.Root().Hex())}
+	}
+	for _, hi := range his {
+		value, err := o.GetValueInPos(hi)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(value, rt.GetValueInPos(hi)) {
+			return &DivergenceError{step, fmt.Sprintf("value in the position of %s is %x, the reference %x", hi.Hex(), value, rt.GetValueInPos(hi))}
+		}
+		proof, err := o.GenerateProof(hi)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(proof, rt.GenerateProof(hi)) {
+			return &DivergenceError{step, fmt.Sprintf("proof of %s is %x, the reference %x", hi.Hex(), proof, rt.GenerateProof(hi))}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package merkletree
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceTreeVector4(t *testing.T) {
+	rt, err := NewReferenceTree(4, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, EmptyNodeValue, rt.Root())
+
+	zeros := make([]byte, 32, 32)
+	zeros[31] = 1
+	assert.Nil(t, rt.Add(vt{zeros, uint32(1)}))
+	v := vt{zeros, uint32(2)}
+	assert.Nil(t, rt.Add(v))
+	hi := HashBytes(v.Bytes()[:v.IndexLength()])
+	assert.Equal(t, "0xc1b95ffbb999a6dd7a472a610a98891ffae95cc973d1d1e21acfdd68db830b51", rt.Root().Hex())
+	assert.Equal(t, "00000000000000000000000000000000000000000000000000000000000000023cf025e4b4fc3ebe57374bf0e0c78ceb0009bdc4466a45174d80e8f508d1a4e3", hex.EncodeToString(rt.GenerateProof(hi)))
+	assert.Equal(t, v.Bytes(), rt.GetValueInPos(hi))
+
+	assert.Equal(t, ErrNodeAlreadyExists, rt.Add(v))
+	assert.Equal(t, ErrEmptyIndex, rt.Add(vt{zeros, 0}))
+	assert.Equal(t, ErrIndexLengthOutOfRange, rt.Add(vt{zeros, 33}))
+	assert.Equal(t, ErrEmptyValue, rt.Add(vt{make([]byte, 32), 1}))
+	_, err = NewReferenceTree(1, nil)
+	assert.Equal(t, ErrInvalidNumLevels, err)
+}
+
+func TestCheckAgainstReference(t *testing.T) {
+	for _, numLevels := range []int{4, 8, 140} {
+		for seed := int64(0); seed < 5; seed++ {
+			mt := newTestingMerkle(t, numLevels)
+			keys := countKeys(mt)
+			assert.Nil(t, mt.CheckRandomAgainstReference(seed, 40))
+			// the MT is not changed by the check
+			assert.Equal(t, EmptyNodeValue, mt.Root())
+			assert.Equal(t, keys, countKeys(mt))
+
+			// the leafs already in the tree are added to the reference tree
+			for _, v := range randomValues(rand.New(rand.NewSource(seed)), 10) {
+				_ = mt.Add(v)
+			}
+			root := mt.Root()
+			keys = countKeys(mt)
+			assert.Nil(t, mt.CheckRandomAgainstReference(seed+100, 10))
+			assert.Equal(t, root, mt.Root())
+			assert.Equal(t, keys, countKeys(mt))
+			mt.storage.Close()
+		}
+	}
+}
+
+func TestCheckAgainstReferenceHashers(t *testing.T) {
+	for _, h := range []Hasher{PoseidonHasher, MiMC7Hasher} {
+		mt := newTestingMerkle(t, 140)
+		tree, err := NewWithOptions(mt.storage, 16, Options{Namespace: "reference", Hasher: h})
+		assert.Nil(t, err)
+		assert.Nil(t, tree.CheckRandomAgainstReference(1, 20))
+		mt.storage.Close()
+	}
+}
+
+func TestCheckAgainstReferenceDivergence(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	// a root that is not the one of the leafs of the tree
+	mt.root = HashBytes([]byte("not a root"))
+	mt.Insert(mt.root, finalNodeType, 1, []byte("leaf"))
+	err := mt.CheckAgainstReference(nil)
+	var divergence *DivergenceError
+	assert.True(t, errors.As(err, &divergence))
+	assert.Equal(t, 0, divergence.Step)
+}
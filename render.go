@@ -0,0 +1,188 @@
+package merkletree
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// nodeTypeName returns the human readable name of a node type
+func nodeTypeName(nodeType byte) string {
+	switch nodeType {
+	case normalNodeType:
+		return "normal"
+	case finalNodeType:
+		return "final"
+	case valueNodeType:
+		return "value"
+	case EmptyNodeType:
+		return "empty"
+	}
+	return "unknown"
+}
+
+// jsonNode is the representation of a node used in the JSON output of the tree
+type jsonNode struct {
+	Hash        string    `json:"hash"`
+	Type        string    `json:"type"`
+	Level       int       `json:"level"`
+	IndexLength uint32    `json:"indexLength,omitempty"`
+	Value       string    `json:"value,omitempty"`
+	Left        *jsonNode `json:"left,omitempty"`
+	Right       *jsonNode `json:"right,omitempty"`
+}
+
+func (mt *MerkleTree) jsonLevel(ctx context.Context, parent Hash, iLevel int, maxLevel int) (*jsonNode, error) {
+	nodeType, indexLength, nodeBytes, err := mt.getContext(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	n := &jsonNode{
+		Hash:  parent.Hex(),
+		Type:  nodeTypeName(nodeType),
+		Level: iLevel,
+	}
+	if nodeType == byte(finalNodeType) || nodeType == byte(valueNodeType) {
+		n.IndexLength = indexLength
+		n.Value = BytesToHex(nodeBytes)
+		return n, nil
+	}
+	if nodeType != byte(normalNodeType) || iLevel+1 >= maxLevel {
+		return n, nil
+	}
+	node := parseNodeBytes(nodeBytes)
+	if n.Left, err = mt.jsonLevel(ctx, node.ChildL, iLevel+1, maxLevel); err != nil {
+		return nil, err
+	}
+	if n.Right, err = mt.jsonLevel(ctx, node.ChildR, iLevel+1, maxLevel); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// JSONMT writes into w the tree as a nested JSON structure, rendering the levels
+// from the root until the specified maxLevel, as PrintLevelsMT
+func (mt *MerkleTree) JSONMT(w io.Writer, maxLevel int) error {
+	return mt.JSONMTContext(context.Background(), w, maxLevel)
+}
+
+// JSONMTContext writes into w the tree as JSONMT, returning the error of the context if
+// it is done before all the nodes have been read
+func (mt *MerkleTree) JSONMTContext(ctx context.Context, w io.Writer, maxLevel int) error {
+	return mt.jsonMT(ctx, w, mt.numLevels-1-maxLevel)
+}
+
+// jsonMT writes into w the tree as a nested JSON structure, with the nodes whose depth
+// is lower than the limit, and the root
+func (mt *MerkleTree) jsonMT(ctx context.Context, w io.Writer, limit int) error {
+	n, err := mt.jsonLevel(ctx, mt.root, 0, limit)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(n)
+}
+
+// JSONFullMT writes into w the tree as a nested JSON structure, with all the levels
+func (mt *MerkleTree) JSONFullMT(w io.Writer) error {
+	return mt.JSONFullMTContext(context.Background(), w)
+}
+
+// JSONFullMTContext writes into w the tree as JSONFullMT, returning the error of the
+// context if it is done before all the nodes have been read
+func (mt *MerkleTree) JSONFullMTContext(ctx context.Context, w io.Writer) error {
+	return mt.jsonMT(ctx, w, mt.numLevels)
+}
+
+// dotWriter keeps the state of the Graphviz rendering of the tree
+type dotWriter struct {
+	mt      *MerkleTree
+	w       io.Writer
+	empties int
+}
+
+// shortHex returns the first bytes of the hash in hex, used as label of the nodes
+func shortHex(h Hash) string {
+	return h.Hex()[:10]
+}
+
+func (d *dotWriter) printf(format string, a ...interface{}) error {
+	_, err := fmt.Fprintf(d.w, format, a...)
+	return err
+}
+
+// dotLevel writes the node and its children, returning the id of the node in the graph
+func (d *dotWriter) dotLevel(ctx context.Context, parent Hash, iLevel int, maxLevel int) (string, error) {
+	nodeType, _, nodeBytes, err := d.mt.getContext(ctx, parent)
+	if err != nil {
+		return "", err
+	}
+	id := "\"" + parent.Hex() + "\""
+	switch nodeType {
+	case normalNodeType:
+		err = d.printf("%s [label=\"%s\"];\n", id, shortHex(parent))
+	case finalNodeType:
+		err = d.printf("%s [label=\"%s\\nleaf: %s\",style=filled,fillcolor=lightblue];\n",
+			id, shortHex(parent), shortHex(d.mt.hasher.HashBytes(nodeBytes)))
+	case valueNodeType:
+		err = d.printf("%s [label=\"%s\",shape=ellipse,style=filled,fillcolor=palegreen];\n",
+			id, shortHex(parent))
+	default:
+		d.empties++
+		id = fmt.Sprintf("empty%d", d.empties)
+		err = d.printf("%s [label=\"\",shape=point];\n", id)
+	}
+	if err != nil {
+		return "", err
+	}
+	if nodeType != byte(normalNodeType) || iLevel+1 >= maxLevel {
+		return id, nil
+	}
+	node := parseNodeBytes(nodeBytes)
+	for _, child := range []Hash{node.ChildL, node.ChildR} {
+		childID, err := d.dotLevel(ctx, child, iLevel+1, maxLevel)
+		if err != nil {
+			return "", err
+		}
+		if err := d.printf("%s -> %s;\n", id, childID); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// GraphvizMT writes into w the tree in the Graphviz DOT format, rendering the levels
+// from the root until the specified maxLevel, as PrintLevelsMT
+func (mt *MerkleTree) GraphvizMT(w io.Writer, maxLevel int) error {
+	return mt.GraphvizMTContext(context.Background(), w, maxLevel)
+}
+
+// GraphvizMTContext writes into w the tree as GraphvizMT, returning the error of the
+// context if it is done before all the nodes have been read
+func (mt *MerkleTree) GraphvizMTContext(ctx context.Context, w io.Writer, maxLevel int) error {
+	return mt.graphvizMT(ctx, w, mt.numLevels-1-maxLevel)
+}
+
+// graphvizMT writes into w the tree in the Graphviz DOT format, with the nodes whose
+// depth is lower than the limit, and the root
+func (mt *MerkleTree) graphvizMT(ctx context.Context, w io.Writer, limit int) error {
+	d := dotWriter{mt: mt, w: w}
+	if err := d.printf("digraph merkletree {\nnode [fontname=Monospace,fontsize=10,shape=box];\n"); err != nil {
+		return err
+	}
+	if _, err := d.dotLevel(ctx, mt.root, 0, limit); err != nil {
+		return err
+	}
+	return d.printf("}\n")
+}
+
+// GraphvizFullMT writes into w the tree in the Graphviz DOT format, with all the levels
+func (mt *MerkleTree) GraphvizFullMT(w io.Writer) error {
+	return mt.GraphvizFullMTContext(context.Background(), w)
+}
+
+// GraphvizFullMTContext writes into w the tree as GraphvizFullMT, returning the error of
+// the context if it is done before all the nodes have been read
+func (mt *MerkleTree) GraphvizFullMTContext(ctx context.Context, w io.Writer) error {
+	return mt.graphvizMT(ctx, w, mt.numLevels)
+}
@@ -0,0 +1,133 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphvizMT(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	var b bytes.Buffer
+	assert.Nil(t, mt.GraphvizFullMT(&b))
+	assert.Equal(t, "digraph merkletree {\nnode [fontname=Monospace,fontsize=10,shape=box];\nempty1 [label=\"\",shape=point];\n}\n", b.String())
+
+	assert.Nil(t, mt.Add(newTestBytesLeaf("this is a test leaf", 15)))
+	assert.Nil(t, mt.Add(newTestBytesLeaf("this is a second test leaf", 15)))
+	assert.Nil(t, mt.Add(newTestBytesLeaf("this is a third test leaf", 15)))
+
+	b.Reset()
+	assert.Nil(t, mt.GraphvizFullMT(&b))
+	dot := b.String()
+	assert.True(t, strings.HasPrefix(dot, "digraph merkletree {"))
+	assert.Contains(t, dot, "\""+mt.Root().Hex()+"\" [label=\""+mt.Root().Hex()[:10]+"\"];")
+	assert.Equal(t, 3, strings.Count(dot, "fillcolor=lightblue"))
+
+	// only the root node
+	b.Reset()
+	assert.Nil(t, mt.GraphvizMT(&b, mt.NumLevels()-2))
+	assert.NotContains(t, b.String(), "->")
+}
+
+func TestJSONMT(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	leaf := newTestBytesLeaf("this is a test leaf", 15)
+	assert.Nil(t, mt.Add(leaf))
+
+	var b bytes.Buffer
+	assert.Nil(t, mt.JSONFullMT(&b))
+	var n jsonNode
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &n))
+	assert.Equal(t, mt.Root().Hex(), n.Hash)
+	assert.Equal(t, "final", n.Type)
+	assert.Equal(t, uint32(15), n.IndexLength)
+	assert.Equal(t, BytesToHex(leaf.Bytes()), n.Value)
+
+	assert.Nil(t, mt.Add(newTestBytesLeaf("this is a second test leaf", 15)))
+	b.Reset()
+	assert.Nil(t, mt.JSONFullMT(&b))
+	n = jsonNode{}
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &n))
+	assert.Equal(t, "normal", n.Type)
+	assert.NotNil(t, n.Left)
+	assert.NotNil(t, n.Right)
+
+	// limit the depth to the root node
+	b.Reset()
+	assert.Nil(t, mt.JSONMT(&b, mt.NumLevels()-2))
+	n = jsonNode{}
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &n))
+	assert.Nil(t, n.Left)
+	assert.Nil(t, n.Right)
+}
+
+// printedNodes returns the hashes of the non empty nodes printed by PrintLevelsMT
+func printedNodes(t *testing.T, mt *MerkleTree, maxLevel int) map[string]bool {
+	r, w, err := os.Pipe()
+	assert.Nil(t, err)
+	stdout, colorOutput := os.Stdout, color.Output
+	os.Stdout, color.Output = w, w
+	mt.PrintLevelsMT(maxLevel)
+	os.Stdout, color.Output = stdout, colorOutput
+	assert.Nil(t, w.Close())
+	out, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+
+	nodes := make(map[string]bool)
+	for _, m := range regexp.MustCompile(`level \d+ - '(0x[0-9a-f]+)'`).FindAllStringSubmatch(string(out), -1) {
+		if m[1] != EmptyNodeValue.Hex() {
+			nodes[m[1]] = true
+		}
+	}
+	return nodes
+}
+
+// jsonNodes returns the hashes of the non empty nodes of the JSON rendering
+func jsonNodes(n *jsonNode, nodes map[string]bool) map[string]bool {
+	if n == nil {
+		return nodes
+	}
+	if n.Type != "empty" {
+		nodes[n.Hash] = true
+	}
+	jsonNodes(n.Left, nodes)
+	return jsonNodes(n.Right, nodes)
+}
+
+func TestRenderLevelsAsPrintLevelsMT(t *testing.T) {
+	mt := newTestingMerkle(t, 8)
+	defer mt.storage.Close()
+
+	for i := 0; i < 20; i++ {
+		_ = mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15))
+	}
+	for maxLevel := 0; maxLevel < mt.NumLevels(); maxLevel++ {
+		printed := printedNodes(t, mt, maxLevel)
+
+		var b bytes.Buffer
+		assert.Nil(t, mt.JSONMT(&b, maxLevel))
+		var n jsonNode
+		assert.Nil(t, json.Unmarshal(b.Bytes(), &n))
+		assert.Equal(t, printed, jsonNodes(&n, make(map[string]bool)))
+
+		b.Reset()
+		assert.Nil(t, mt.GraphvizMT(&b, maxLevel))
+		rendered := make(map[string]bool)
+		for _, m := range regexp.MustCompile(`(?m)^"(0x[0-9a-f]+)" \[`).FindAllStringSubmatch(b.String(), -1) {
+			rendered[m[1]] = true
+		}
+		assert.Equal(t, printed, rendered)
+	}
+}
@@ -0,0 +1,127 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+)
+
+// Stats contains the statistics of the nodes reachable from the root of the tree
+type Stats struct {
+	LeafCount      int         // number of leafs (final and value nodes)
+	NormalNodes    int         // number of middle nodes
+	FinalNodes     int         // number of final nodes
+	ValueNodes     int         // number of value nodes, at the bottom of the tree
+	FinalDepths    map[int]int // number of final nodes at each depth, being 0 the root
+	AvgProofLength float64     // average number of siblings in the proofs of the leafs, 0 in the TrackedStats
+	StorageBytes   int         // bytes that the nodes use in the database, keys included
+}
+
+// nodeStorageSize returns the bytes that a node uses in the database, with the key
+// prefixed by the namespace when the nodes are not shared
+func (mt *MerkleTree) nodeStorageSize(nodeBytes []byte) int {
+	return len(mt.nodeKey(EmptyNodeValue)) + 1 + 4 + len(nodeBytes)
+}
+
+// count adds n nodes of the given type at the given depth, each one using storageSize
+// bytes in the database, to the stats, n can be negative
+func (s *Stats) count(nodeType byte, depth int, storageSize int, n int) {
+	switch nodeType {
+	case normalNodeType:
+		s.NormalNodes += n
+	case finalNodeType:
+		s.FinalNodes += n
+		s.LeafCount += n
+		s.FinalDepths[depth] += n
+		if s.FinalDepths[depth] == 0 {
+			delete(s.FinalDepths, depth)
+		}
+	case valueNodeType:
+		s.ValueNodes += n
+		s.LeafCount += n
+	default:
+		return
+	}
+	s.StorageBytes += n * storageSize
+}
+
+func (mt *MerkleTree) statsLevel(ctx context.Context, s *Stats, nodeHash Hash, depth int, proofLength int, proofLengths *int) error {
+	if bytes.Equal(nodeHash[:], EmptyNodeValue[:]) {
+		return nil
+	}
+	nodeType, _, nodeBytes, err := mt.getContext(ctx, nodeHash)
+	if err != nil {
+		return err
+	}
+	s.count(nodeType, depth, mt.nodeStorageSize(nodeBytes), 1)
+	if nodeType == byte(finalNodeType) || nodeType == byte(valueNodeType) {
+		*proofLengths += proofLength
+		return nil
+	}
+	if nodeType != byte(normalNodeType) {
+		return nil
+	}
+	node := parseNodeBytes(nodeBytes)
+	childProofLength := proofLength
+	if !bytes.Equal(node.ChildL[:], EmptyNodeValue[:]) && !bytes.Equal(node.ChildR[:], EmptyNodeValue[:]) {
+		// each child is the non empty sibling of the other one
+		childProofLength++
+	}
+	if err := mt.statsLevel(ctx, s, node.ChildL, depth+1, childProofLength, proofLengths); err != nil {
+		return err
+	}
+	return mt.statsLevel(ctx, s, node.ChildR, depth+1, childProofLength, proofLengths)
+}
+
+// Stats traverses the tree from the root and returns its statistics
+func (mt *MerkleTree) Stats() (*Stats, error) {
+	return mt.StatsContext(context.Background())
+}
+
+// StatsContext returns the statistics of the tree as Stats, returning the error of the
+// context if it is done before the traversal has finished
+func (mt *MerkleTree) StatsContext(ctx context.Context) (*Stats, error) {
+	s := &Stats{FinalDepths: make(map[int]int)}
+	proofLengths := 0
+	if err := mt.statsLevel(ctx, s, mt.root, 0, 0, &proofLengths); err != nil {
+		return nil, err
+	}
+	if s.LeafCount > 0 {
+		s.AvgProofLength = float64(proofLengths) / float64(s.LeafCount)
+	}
+	return s, nil
+}
+
+// TrackStats computes the statistics of the tree and from then on keeps them
+// updated on each Add, without traversing the tree again. The AvgProofLength is
+// not tracked, as adding a leaf changes the proofs of the leafs of its sibling
+// subtree, so it can only be computed by Stats
+func (mt *MerkleTree) TrackStats() error {
+	s, err := mt.Stats()
+	if err != nil {
+		return err
+	}
+	s.AvgProofLength = 0
+	mt.stats = s
+	return nil
+}
+
+// TrackedStats returns a copy of the statistics maintained since TrackStats was
+// called, or nil if they are not being tracked. The AvgProofLength is always 0
+func (mt *MerkleTree) TrackedStats() *Stats {
+	if mt.stats == nil {
+		return nil
+	}
+	s := *mt.stats
+	s.FinalDepths = make(map[int]int)
+	for depth, n := range mt.stats.FinalDepths {
+		s.FinalDepths[depth] = n
+	}
+	return &s
+}
+
+// trackStats updates the tracked stats, if they are enabled
+func (mt *MerkleTree) trackStats(nodeType byte, depth int, nodeBytes []byte, n int) {
+	if mt.stats != nil {
+		mt.stats.count(nodeType, depth, mt.nodeStorageSize(nodeBytes), n)
+	}
+}
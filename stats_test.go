@@ -0,0 +1,105 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	s, err := mt.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, s.LeafCount)
+	assert.Equal(t, 0, s.StorageBytes)
+
+	leaf := newTestBytesLeaf("this is a test leaf", 15)
+	assert.Nil(t, mt.Add(leaf))
+	s, err = mt.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, s.LeafCount)
+	assert.Equal(t, 1, s.FinalNodes)
+	assert.Equal(t, 0, s.NormalNodes)
+	assert.Equal(t, map[int]int{0: 1}, s.FinalDepths)
+	assert.Equal(t, float64(0), s.AvgProofLength)
+	assert.Equal(t, 32+5+len(leaf.Bytes()), s.StorageBytes)
+
+	// the two leafs of the test vector are in the first level
+	assert.Nil(t, mt.Add(newTestBytesLeaf("this is a second test leaf", 15)))
+	s, err = mt.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, s.LeafCount)
+	assert.Equal(t, 1, s.NormalNodes)
+	assert.Equal(t, map[int]int{1: 2}, s.FinalDepths)
+	assert.Equal(t, float64(1), s.AvgProofLength)
+
+	// the keys of the nodes of a namespace that does not share them include its prefix
+	alice, err := NewWithOptions(mt.storage, 140, Options{Namespace: "alice"})
+	assert.Nil(t, err)
+	assert.Nil(t, alice.TrackStats())
+	assert.Nil(t, alice.Add(leaf))
+	s, err = alice.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, len(alice.nodeKey(alice.Root()))+5+len(leaf.Bytes()), s.StorageBytes)
+	assert.True(t, s.StorageBytes > 32+5+len(leaf.Bytes()))
+	assert.Equal(t, s.StorageBytes, alice.TrackedStats().StorageBytes)
+}
+
+func TestTrackStats(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	assert.Nil(t, mt.TrackedStats())
+	assert.Nil(t, mt.Add(newTestBytesLeaf("this is a test leaf", 15)))
+	assert.Nil(t, mt.TrackStats())
+
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	s, err := mt.Stats()
+	assert.Nil(t, err)
+	tracked := mt.TrackedStats()
+	assert.Equal(t, 101, tracked.LeafCount)
+	// the AvgProofLength is not tracked
+	assert.Equal(t, float64(0), tracked.AvgProofLength)
+	s.AvgProofLength = 0
+	assert.Equal(t, s, tracked)
+
+	// values at the bottom of a small tree
+	mt4 := newTestingMerkle(t, 4)
+	defer mt4.storage.Close()
+	assert.Nil(t, mt4.TrackStats())
+	var leafs []testBytesLeaf
+	for i := 0; i < 20; i++ {
+		leaf := newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)
+		if mt4.Add(leaf) == nil {
+			leafs = append(leafs, leaf)
+		}
+	}
+	assertTrackedStats(t, mt4)
+
+	// the rejected leafs do not change the stats
+	for _, leaf := range leafs {
+		assert.NotNil(t, mt4.Add(leaf))
+	}
+	assertTrackedStats(t, mt4)
+
+	for _, leaf := range leafs {
+		assert.Nil(t, mt4.Delete(leaf.Hi()))
+		assertTrackedStats(t, mt4)
+	}
+	assert.Equal(t, Stats{FinalDepths: map[int]int{}}, *mt4.TrackedStats())
+}
+
+// assertTrackedStats asserts that the tracked stats of the MT are the ones of its nodes
+func assertTrackedStats(t *testing.T, mt *MerkleTree) {
+	s, err := mt.Stats()
+	assert.Nil(t, err)
+	tracked := mt.TrackedStats()
+	assert.Equal(t, float64(0), tracked.AvgProofLength)
+	s.AvgProofLength = 0
+	assert.Equal(t, s, tracked)
+}
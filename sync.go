@@ -0,0 +1,120 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// NodeSource is the transport used to fetch the nodes of a remote replica of the tree
+type NodeSource interface {
+	// GetNode returns the node stored under the hash, encoded as in the database
+	GetNode(hash Hash) ([]byte, error)
+}
+
+// LocalNodeSource is a NodeSource that fetches the nodes from a MerkleTree of the same process
+type LocalNodeSource struct {
+	mt *MerkleTree
+}
+
+// NewLocalNodeSource returns a NodeSource that fetches the nodes from the MerkleTree
+func NewLocalNodeSource(mt *MerkleTree) *LocalNodeSource {
+	return &LocalNodeSource{mt: mt}
+}
+
+// GetNode returns the node stored under the hash in the database of the MerkleTree
+func (s *LocalNodeSource) GetNode(hash Hash) ([]byte, error) {
+	nodeType, indexLength, nodeBytes, err := s.mt.Get(hash)
+	if err != nil {
+		return nil, err
+	}
+	return encodeNode(nodeType, indexLength, nodeBytes), nil
+}
+
+// syncNode is a node pending to be fetched, with the route from the root to its position
+type syncNode struct {
+	hash  Hash
+	route []bool
+}
+
+// Sync fetches from src the nodes of the tree of remoteRoot that are not in the local
+// database, starting from the root and skipping the subtrees that are already stored.
+// Each node is validated on arrival, and only when all of them have been fetched they
+// are stored, and remoteRoot becomes the root of the MT with its Metadata in a single write
+func (mt *MerkleTree) Sync(src NodeSource, remoteRoot Hash) error {
+	return mt.SyncContext(context.Background(), src, remoteRoot)
+}
+
+// SyncContext fetches the nodes of remoteRoot as Sync, returning the error of the context
+// if it is done before all the nodes have been fetched, without storing any of them, or
+// before the leafs of remoteRoot have been counted, without switching the root
+func (mt *MerkleTree) SyncContext(ctx context.Context, src NodeSource, remoteRoot Hash) error {
+	batch := new(leveldb.Batch)
+	fetched := make(map[Hash]bool)
+	pending := []syncNode{{remoteRoot, []bool{}}}
+	for len(pending) > 0 {
+		n := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if bytes.Equal(n.hash[:], EmptyNodeValue[:]) || fetched[n.hash] {
+			continue
+		}
+		// the nodes are content addressed, so if a node is stored, its subtree also is
+		stored, err := mt.storage.Has(mt.nodeKey(n.hash), nil)
+		if err != nil {
+			return storageError(err)
+		}
+		if stored {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		value, err := src.GetNode(n.hash)
+		if err != nil {
+			return err
+		}
+		nodeType, indexLength, nodeBytes, err := decodeNode(value)
+		if err != nil {
+			return &CorruptNodeError{n.hash, err.Error()}
+		}
+		if reason := mt.checkNode(n.hash, n.route, nodeType, indexLength, nodeBytes); reason != "" {
+			return &CorruptNodeError{n.hash, reason}
+		}
+		fetched[n.hash] = true
+		batch.Put(mt.nodeKey(n.hash), encodeNode(nodeType, indexLength, nodeBytes))
+		level := len(n.route)
+		switch nodeType {
+		case normalNodeType:
+			node := parseNodeBytes(nodeBytes)
+			pending = append(pending,
+				syncNode{node.ChildR, append(n.route[:level:level], true)},
+				syncNode{node.ChildL, append(n.route[:level:level], false)})
+		case finalNodeType:
+			// as in Add, the value of the leaf is also stored as a value node, unless the
+			// final node is at the bottom level, where both have the same hash
+			leafHash := mt.hasher.HashBytes(nodeBytes)
+			if leafHash != n.hash {
+				batch.Put(mt.nodeKey(leafHash), encodeNode(valueNodeType, indexLength, nodeBytes))
+			}
+		}
+	}
+	// the nodes are written before the root, that is only switched once its leafs are counted
+	if err := mt.storage.Write(batch, nil); err != nil {
+		return storageError(err)
+	}
+	leafs, err := mt.leafs(ctx, remoteRoot)
+	if err != nil {
+		return err
+	}
+	oldRoot := mt.root
+	mt.root = remoteRoot
+	if err := mt.storeRootAndLeafCount(uint64(len(leafs))); err != nil {
+		mt.root = oldRoot
+		return err
+	}
+	if mt.stats != nil {
+		return mt.TrackStats()
+	}
+	return nil
+}
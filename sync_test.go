@@ -0,0 +1,144 @@
+package merkletree
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingNodeSource counts the nodes fetched from the source, and can return a corrupted node
+type countingNodeSource struct {
+	NodeSource
+	fetched int
+	corrupt Hash
+}
+
+func (s *countingNodeSource) GetNode(hash Hash) ([]byte, error) {
+	s.fetched++
+	value, err := s.NodeSource.GetNode(hash)
+	if err == nil && hash == s.corrupt {
+		value[len(value)-1]++
+	}
+	return value, err
+}
+
+func TestSync(t *testing.T) {
+	mt1 := newTestingMerkle(t, 140)
+	defer mt1.storage.Close()
+	mt2 := newTestingMerkle(t, 140)
+	defer mt2.storage.Close()
+
+	for i := 0; i < 50; i++ {
+		assert.Nil(t, mt1.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	src := &countingNodeSource{NodeSource: NewLocalNodeSource(mt1)}
+	assert.Nil(t, mt2.Sync(src, mt1.Root()))
+	assert.Equal(t, mt1.Root(), mt2.Root())
+	assert.Equal(t, 0, len(mt2.Verify(mt2.Root())))
+	assert.Equal(t, uint64(50), mt2.Metadata().LeafCount)
+	s1, err := mt1.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, s1.NormalNodes+s1.FinalNodes, src.fetched)
+
+	data, found, err := mt2.Lookup([]byte("7 this is a tes"))
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("t leaf"), data)
+
+	// after adding a leaf, only the nodes of its path are fetched
+	leaf := newTestBytesLeaf("this is a test leaf", 15)
+	assert.Nil(t, mt1.Add(leaf))
+	src.fetched = 0
+	assert.Nil(t, mt2.Sync(src, mt1.Root()))
+	assert.Equal(t, mt1.Root(), mt2.Root())
+	proof, err := mt1.GenerateProof(leaf.Hi())
+	assert.Nil(t, err)
+	// the new path has a node for each sibling, plus the final node of the leaf, and
+	// the sibling of the leaf if it was a final node moved down
+	assert.True(t, src.fetched <= (len(proof)-32)/32+2)
+
+	// the root of the replica can be reopened from the database
+	mt3, err := New(mt2.storage, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, mt1.Root(), mt3.Root())
+	assert.Nil(t, mt3.Add(newTestBytesLeaf("this is a second test leaf", 15)))
+	assert.Nil(t, mt1.Add(newTestBytesLeaf("this is a second test leaf", 15)))
+	assert.Equal(t, mt1.Root(), mt3.Root())
+
+	// in a small tree, the final nodes at the bottom level are synced as final nodes
+	small1 := newTestingMerkle(t, 4)
+	defer small1.storage.Close()
+	small2 := newTestingMerkle(t, 4)
+	defer small2.storage.Close()
+	for i := 0; i < 20; i++ {
+		_ = small1.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15))
+	}
+	assert.Nil(t, small2.Sync(NewLocalNodeSource(small1), small1.Root()))
+	assert.Equal(t, small1.Root(), small2.Root())
+	s1, err = small1.Stats()
+	assert.Nil(t, err)
+	s2, err := small2.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, s1, s2)
+	assert.Equal(t, 0, s2.ValueNodes)
+}
+
+func TestSyncInvalidNode(t *testing.T) {
+	mt1 := newTestingMerkle(t, 140)
+	defer mt1.storage.Close()
+	mt2 := newTestingMerkle(t, 140)
+	defer mt2.storage.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, mt1.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	_, _, rootBytes, err := mt1.Get(mt1.Root())
+	assert.Nil(t, err)
+	src := &countingNodeSource{
+		NodeSource: NewLocalNodeSource(mt1),
+		corrupt:    parseNodeBytes(rootBytes).ChildR,
+	}
+	assert.NotNil(t, mt2.Sync(src, mt1.Root()))
+	assert.Equal(t, EmptyNodeValue, mt2.Root())
+
+	// nothing has been stored
+	_, _, _, err = mt2.Get(mt1.Root())
+	assert.NotNil(t, err)
+}
+
+// cancellingNodeSource cancels the context of the sync once a node has been fetched
+type cancellingNodeSource struct {
+	NodeSource
+	cancel context.CancelFunc
+}
+
+func (s *cancellingNodeSource) GetNode(hash Hash) ([]byte, error) {
+	s.cancel()
+	return s.NodeSource.GetNode(hash)
+}
+
+func TestSyncCancelledCount(t *testing.T) {
+	mt1 := newTestingMerkle(t, 140)
+	defer mt1.storage.Close()
+	mt2 := newTestingMerkle(t, 140)
+	defer mt2.storage.Close()
+
+	// the tree of a single leaf is fetched with its root, and then its leafs are counted
+	assert.Nil(t, mt1.Add(newTestBytesLeaf("this is a test leaf", 15)))
+	ctx, cancel := context.WithCancel(context.Background())
+	src := &cancellingNodeSource{NewLocalNodeSource(mt1), cancel}
+	assert.Equal(t, context.Canceled, mt2.SyncContext(ctx, src, mt1.Root()))
+	assert.Equal(t, EmptyNodeValue, mt2.Root())
+	assert.Equal(t, uint64(0), mt2.Metadata().LeafCount)
+
+	mt2, err := New(mt2.storage, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, EmptyNodeValue, mt2.Root())
+	assert.Equal(t, uint64(0), mt2.Metadata().LeafCount)
+
+	assert.Nil(t, mt2.Sync(NewLocalNodeSource(mt1), mt1.Root()))
+	assert.Equal(t, mt1.Root(), mt2.Root())
+	assert.Equal(t, uint64(1), mt2.Metadata().LeafCount)
+}
@@ -0,0 +1,92 @@
+package merkletree
+
+import "errors"
+
+var (
+	// ErrTxDone is an error that indicates that the transaction has already been committed or rolled back
+	ErrTxDone = errors.New("transaction already committed or rolled back")
+	// ErrTxConflict is an error that indicates that the root of the merkletree has changed since the transaction began
+	ErrTxConflict = errors.New("merkletree root changed since the transaction began")
+)
+
+// Tx is a group of changes of a MerkleTree that are staged in memory, over the committed
+// tree, until they are written all together by Commit or discarded by Rollback
+type Tx struct {
+	mt       *MerkleTree // tree where the transaction is committed
+	staged   *MerkleTree // copy of the tree that keeps the writes in memory
+	baseRoot Hash        // root of the tree when the transaction began
+	done     bool
+}
+
+// Begin starts a transaction over the current root of the MT
+func (mt *MerkleTree) Begin() *Tx {
+	return &Tx{
+		mt:       mt,
+		staged:   mt.stage(),
+		baseRoot: mt.root,
+	}
+}
+
+// Add stages the addition of the leaf
+func (tx *Tx) Add(v Value) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	return tx.staged.Add(v)
+}
+
+// Update stages the replacement of the value of the leaf with the same index
+func (tx *Tx) Update(v Value) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	return tx.staged.Update(v)
+}
+
+// Delete stages the removal of the leaf of the Hi
+func (tx *Tx) Delete(hi Hash) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	return tx.staged.Delete(hi)
+}
+
+// Root returns the root of the tree with the staged changes
+func (tx *Tx) Root() Hash {
+	return tx.staged.Root()
+}
+
+// GetValueInPos returns the value in the position of the Hi, with the staged changes
+func (tx *Tx) GetValueInPos(hi Hash) ([]byte, error) {
+	return tx.staged.GetValueInPos(hi)
+}
+
+// GenerateProof generates the Merkle Proof of the Hi for the root with the staged changes
+func (tx *Tx) GenerateProof(hi Hash) ([]byte, error) {
+	return tx.staged.GenerateProof(hi)
+}
+
+// Commit writes the staged nodes and the new root to the database in a single batch.
+// Returns ErrTxConflict if the root of the MT has changed since the transaction began
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	return tx.write()
+}
+
+// write writes the staged changes to the database, returning ErrTxConflict if the root
+// of the MT has changed since the transaction began
+func (tx *Tx) write() error {
+	if tx.mt.root != tx.baseRoot {
+		return ErrTxConflict
+	}
+	return tx.mt.writeStaged(tx.staged)
+}
+
+// Rollback discards the staged changes, leaving the MT as it was when the transaction began
+func (tx *Tx) Rollback() {
+	tx.done = true
+	tx.staged = tx.mt.stage()
+}
@@ -0,0 +1,94 @@
+package merkletree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxCommit(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+	mt2 := newTestingMerkle(t, 140)
+	defer mt2.storage.Close()
+
+	leafs := addTestLeafs(t, mt, 10)
+	for _, leaf := range leafs {
+		assert.Nil(t, mt2.Add(leaf))
+	}
+	root := mt.Root()
+
+	tx := mt.Begin()
+	assert.Nil(t, tx.Add(NewLeaf([]byte("tx index"), []byte("data"))))
+	assert.Nil(t, tx.Update(NewLeaf(leafs[0].Index(), []byte("updated data"))))
+	assert.Nil(t, tx.Delete(HashBytes(leafs[1].Index())))
+	assert.Nil(t, mt2.Add(NewLeaf([]byte("tx index"), []byte("data"))))
+	assert.Nil(t, mt2.Update(NewLeaf(leafs[0].Index(), []byte("updated data"))))
+	assert.Nil(t, mt2.Delete(HashBytes(leafs[1].Index())))
+
+	// the reads of the transaction see the staged changes, the ones of the tree do not
+	assert.Equal(t, mt2.Root(), tx.Root())
+	assert.Equal(t, root, mt.Root())
+	value, err := tx.GetValueInPos(HashBytes([]byte("tx index")))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("tx indexdata"), value)
+	_, found, err := mt.Lookup([]byte("tx index"))
+	assert.Nil(t, err)
+	assert.False(t, found)
+	proof, err := tx.GenerateProof(HashBytes(leafs[1].Index()))
+	assert.Nil(t, err)
+	assert.True(t, CheckProof(tx.Root(), proof, HashBytes(leafs[1].Index()), EmptyNodeValue, mt.NumLevels()))
+
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, mt2.Root(), mt.Root())
+	assert.Equal(t, uint64(len(leafs)), mt.Metadata().LeafCount)
+	assert.Equal(t, 0, len(mt.Verify(mt.Root())))
+	assert.Equal(t, ErrTxDone, tx.Add(NewLeaf([]byte("after commit"), []byte("data"))))
+	assert.Equal(t, ErrTxDone, tx.Commit())
+
+	// the committed root is stored
+	mt3, err := New(mt.storage, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, mt2.Root(), mt3.Root())
+	assert.Equal(t, mt.Metadata(), mt3.Metadata())
+}
+
+func TestTxRollback(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	addTestLeafs(t, mt, 10)
+	root := mt.Root()
+	md := mt.Metadata()
+
+	tx := mt.Begin()
+	assert.Nil(t, tx.Add(NewLeaf([]byte("tx index"), []byte("data"))))
+	staged := tx.Root()
+	// a failed change does not affect the rest of the transaction
+	assert.NotNil(t, tx.Add(NewLeaf([]byte("tx index"), []byte("data"))))
+	assert.Equal(t, staged, tx.Root())
+	tx.Rollback()
+	assert.Equal(t, root, mt.Root())
+	assert.Equal(t, root, tx.Root())
+	assert.Equal(t, ErrTxDone, tx.Commit())
+
+	// nothing has been written
+	_, _, _, err := mt.Get(staged)
+	assert.NotNil(t, err)
+	mt2, err := New(mt.storage, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, root, mt2.Root())
+	assert.Equal(t, md, mt2.Metadata())
+}
+
+func TestTxConflict(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	tx := mt.Begin()
+	assert.Nil(t, tx.Add(NewLeaf([]byte("tx index"), []byte("data"))))
+	assert.Nil(t, mt.Add(NewLeaf([]byte("other index"), []byte("data"))))
+	root := mt.Root()
+	assert.Equal(t, ErrTxConflict, tx.Commit())
+	assert.Equal(t, root, mt.Root())
+}
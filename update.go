@@ -0,0 +1,181 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// ErrLeafNotFound is an error that indicates that there is no leaf with the index in the merkletree
+var ErrLeafNotFound = errors.New("leaf not found")
+
+// leafPosition is the node at the end of the path of an index, with the siblings of the path
+type leafPosition struct {
+	siblings    []Hash // siblings of the path, from the root, one for each level above the node
+	nodeHash    Hash
+	nodeType    byte
+	indexLength uint32
+	nodeBytes   []byte
+}
+
+// hasLeaf returns if the node of the position is the leaf of the index, hashed with the Hasher
+func (pos *leafPosition) hasLeaf(h Hasher, hi Hash) bool {
+	if pos.nodeType != byte(finalNodeType) && pos.nodeType != byte(valueNodeType) {
+		return false
+	}
+	nodeHi := h.HashBytes(pos.nodeBytes[:pos.indexLength])
+	return bytes.Equal(nodeHi[:], hi[:])
+}
+
+// findLeaf goes down the path of the Hi from the root, until an empty node or a leaf
+func (mt *MerkleTree) findLeaf(ctx context.Context, hi Hash) (*leafPosition, error) {
+	path := getPath(mt.numLevels, hi)
+	pos := &leafPosition{nodeHash: mt.root}
+	for depth := 0; depth < mt.numLevels; depth++ {
+		if bytes.Equal(pos.nodeHash[:], EmptyNodeValue[:]) {
+			return pos, nil
+		}
+		nodeType, indexLength, nodeBytes, err := mt.getContext(ctx, pos.nodeHash)
+		if err != nil {
+			return nil, err
+		}
+		pos.nodeType, pos.indexLength, pos.nodeBytes = nodeType, indexLength, nodeBytes
+		if nodeType != byte(normalNodeType) || depth == mt.numLevels-1 {
+			return pos, nil
+		}
+		node := parseNodeBytes(nodeBytes)
+		if !path[mt.numLevels-2-depth] {
+			pos.nodeHash = node.ChildL
+			pos.siblings = append(pos.siblings, node.ChildR)
+		} else {
+			pos.nodeHash = node.ChildR
+			pos.siblings = append(pos.siblings, node.ChildL)
+		}
+		pos.nodeType, pos.indexLength, pos.nodeBytes = EmptyNodeType, 0, nil
+	}
+	return pos, nil
+}
+
+// Update replaces the value of the leaf of the MT that has the same index than the
+// given one, returning ErrLeafNotFound if there is none
+func (mt *MerkleTree) Update(v Value) error {
+	return mt.UpdateContext(context.Background(), v)
+}
+
+// UpdateContext replaces the value of the leaf as Update, returning the error of the
+// context if it is done before the path of the leaf has been read
+func (mt *MerkleTree) UpdateContext(ctx context.Context, v Value) error {
+	if err := validateValue(mt.hasher, v); err != nil {
+		return err
+	}
+	hi := mt.hasher.HashBytes(v.Bytes()[:v.IndexLength()])
+	pos, err := mt.findLeaf(ctx, hi)
+	if err != nil {
+		return err
+	}
+	if !pos.hasLeaf(mt.hasher, hi) {
+		return ErrLeafNotFound
+	}
+	if err := mt.Insert(mt.hasher.HashBytes(v.Bytes()), valueNodeType, v.IndexLength(), v.Bytes()); err != nil {
+		return err
+	}
+
+	// the new value goes in the same node than the old one, so the siblings do not change
+	depth := len(pos.siblings)
+	level := mt.numLevels - 1 - depth
+	path := getPath(mt.numLevels, hi)
+	leafHash := calcHashFromLeafAndLevel(mt.hasher, level, path, mt.hasher.HashBytes(v.Bytes()))
+	root, err := mt.replaceLeaf(pos.siblings, path[level:], leafHash, pos.nodeType, v.IndexLength(), v.Bytes())
+	if err != nil {
+		return err
+	}
+	mt.root = root
+	mt.trackStats(pos.nodeType, depth, pos.nodeBytes, -1)
+	mt.trackStats(pos.nodeType, depth, v.Bytes(), 1)
+	return mt.storeRoot(0)
+}
+
+// Delete removes from the MT the leaf of the Hi, returning ErrLeafNotFound if there
+// is none. The resulting root is the same than if the leaf had never been added
+func (mt *MerkleTree) Delete(hi Hash) error {
+	return mt.DeleteContext(context.Background(), hi)
+}
+
+// DeleteContext removes the leaf as Delete, returning the error of the context if it
+// is done before the path of the leaf has been read
+func (mt *MerkleTree) DeleteContext(ctx context.Context, hi Hash) error {
+	pos, err := mt.findLeaf(ctx, hi)
+	if err != nil {
+		return err
+	}
+	if !pos.hasLeaf(mt.hasher, hi) {
+		return ErrLeafNotFound
+	}
+	path := getPath(mt.numLevels, hi)
+	depth := len(pos.siblings)
+
+	// the node of the leaf becomes empty, and if a sibling leaf is left alone in its
+	// subtree, it goes up as a final node until the level where it has a non empty sibling
+	var moved *Leaf
+	var movedType byte
+	movedDepth := 0
+	removedNodes := 0
+	for ; depth > 0; depth-- {
+		sibling := pos.siblings[depth-1]
+		siblingEmpty := bytes.Equal(sibling[:], EmptyNodeValue[:])
+		if moved != nil && !siblingEmpty {
+			break
+		}
+		if moved == nil && !siblingEmpty {
+			siblingType, indexLength, siblingBytes, err := mt.getContext(ctx, sibling)
+			if err != nil {
+				return err
+			}
+			if siblingType != byte(finalNodeType) && siblingType != byte(valueNodeType) {
+				break
+			}
+			moved = &Leaf{data: siblingBytes, indexLength: indexLength}
+			movedType, movedDepth = siblingType, depth
+		}
+		// the parent had the path of the deleted leaf, so it was a normal node
+		removedNodes++
+	}
+
+	nodeHash := EmptyNodeValue
+	if moved != nil {
+		movedPath := getPath(mt.numLevels, mt.hasher.HashBytes(moved.Index()))
+		nodeHash = calcHashFromLeafAndLevel(mt.hasher, mt.numLevels-1-depth, movedPath, mt.hasher.HashBytes(moved.Bytes()))
+		if err := mt.Insert(nodeHash, finalNodeType, moved.IndexLength(), moved.Bytes()); err != nil {
+			return err
+		}
+	}
+	movedLevel := depth
+	// above the collapsed levels, each node has a non empty child
+	for ; depth > 0; depth-- {
+		var node treeNode
+		if !path[mt.numLevels-1-depth] {
+			node = treeNode{
+				ChildL: nodeHash,
+				ChildR: pos.siblings[depth-1],
+			}
+		} else {
+			node = treeNode{
+				ChildL: pos.siblings[depth-1],
+				ChildR: nodeHash,
+			}
+		}
+		if err := mt.Insert(node.Ht(mt.hasher), normalNodeType, 0, node.Bytes()); err != nil {
+			return err
+		}
+		nodeHash = node.Ht(mt.hasher)
+	}
+	// the stats change once all the nodes have been written
+	mt.trackStats(pos.nodeType, len(pos.siblings), pos.nodeBytes, -1)
+	mt.trackStats(normalNodeType, 0, new(treeNode).Bytes(), -removedNodes)
+	if moved != nil {
+		mt.trackStats(movedType, movedDepth, moved.Bytes(), -1)
+		mt.trackStats(finalNodeType, movedLevel, moved.Bytes(), 1)
+	}
+	mt.root = nodeHash
+	return mt.storeRoot(-1)
+}
@@ -0,0 +1,121 @@
+package merkletree
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// addTestLeafs adds to the MT the leafs that can be in a tree of its numLevels, and
+// returns them. A leaf is skipped when its path is the one of a leaf already added
+func addTestLeafs(t *testing.T, mt *MerkleTree, n int) []Leaf {
+	var leafs []Leaf
+	for i := 0; i < n; i++ {
+		leaf := NewLeaf([]byte(strconv.Itoa(i)+" index"), []byte("data"))
+		err := mt.Add(leaf)
+		if err == nil {
+			leafs = append(leafs, leaf)
+			continue
+		}
+		assert.True(t, errors.Is(err, ErrPathCollision))
+	}
+	return leafs
+}
+
+func TestUpdate(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+	mt2 := newTestingMerkle(t, 140)
+	defer mt2.storage.Close()
+	assert.Nil(t, mt.TrackStats())
+
+	leafs := addTestLeafs(t, mt, 20)
+	for i, leaf := range leafs {
+		if i%3 == 0 {
+			leaf = NewLeaf(leaf.Index(), []byte("updated data"))
+			assert.Nil(t, mt.Update(leaf))
+		}
+		assert.Nil(t, mt2.Add(leaf))
+	}
+	// same root than adding the updated values
+	assert.Equal(t, mt2.Root(), mt.Root())
+	assert.Equal(t, uint64(len(leafs)), mt.Metadata().LeafCount)
+	data, found, err := mt.Lookup(leafs[0].Index())
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("updated data"), data)
+	s, err := mt.Stats()
+	assert.Nil(t, err)
+	s.AvgProofLength = 0
+	assert.Equal(t, s, mt.TrackedStats())
+
+	assert.Equal(t, ErrLeafNotFound, mt.Update(NewLeaf([]byte("not in the tree"), []byte("data"))))
+}
+
+func TestDelete(t *testing.T) {
+	for _, numLevels := range []int{140, 8} {
+		mt := newTestingMerkle(t, numLevels)
+		defer mt.storage.Close()
+		mt2 := newTestingMerkle(t, numLevels)
+		defer mt2.storage.Close()
+		assert.Nil(t, mt.TrackStats())
+
+		leafs := addTestLeafs(t, mt, 40)
+		for i, leaf := range leafs {
+			if i%4 == 1 {
+				assert.Nil(t, mt.Delete(HashBytes(leaf.Index())))
+			} else {
+				assert.Nil(t, mt2.Add(leaf))
+			}
+		}
+		// same root than if the deleted leafs had never been added
+		assert.Equal(t, mt2.Root(), mt.Root())
+		assert.Equal(t, mt2.Metadata().LeafCount, mt.Metadata().LeafCount)
+		assert.Equal(t, 0, len(mt.Verify(mt.Root())))
+		s, err := mt.Stats()
+		assert.Nil(t, err)
+		s.AvgProofLength = 0
+		assert.Equal(t, s, mt.TrackedStats())
+
+		assert.Equal(t, ErrLeafNotFound, mt.Delete(HashBytes(leafs[1].Index())))
+
+		// deleting all the leafs gives the empty tree
+		for i, leaf := range leafs {
+			if i%4 != 1 {
+				assert.Nil(t, mt.Delete(HashBytes(leaf.Index())))
+			}
+		}
+		assert.Equal(t, EmptyNodeValue, mt.Root())
+		assert.Equal(t, uint64(0), mt.Metadata().LeafCount)
+		assert.Equal(t, 0, mt.TrackedStats().NormalNodes)
+	}
+}
+
+func TestUpdateDeleteStorageError(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+	assert.Nil(t, mt.TrackStats())
+
+	leafs := addTestLeafs(t, mt, 10)
+	root := mt.Root()
+	stats := mt.TrackedStats()
+	// the nodes can be read, but not written
+	assert.Nil(t, mt.storage.SetReadOnly())
+
+	var storageErr *StorageError
+	err := mt.Update(NewLeaf(leafs[0].Index(), []byte("updated data")))
+	assert.True(t, errors.As(err, &storageErr))
+	assert.Equal(t, root, mt.Root())
+	err = mt.Delete(HashBytes(leafs[1].Index()))
+	assert.True(t, errors.As(err, &storageErr))
+	assert.Equal(t, root, mt.Root())
+	assert.Equal(t, stats, mt.TrackedStats())
+
+	// the stored root is the one of the nodes in the database
+	mt, err = New(mt.storage, 140)
+	assert.Nil(t, err)
+	assert.Equal(t, root, mt.Root())
+	assert.Equal(t, 0, len(mt.Verify(mt.Root())))
+}
@@ -92,3 +92,62 @@ func HexToBytes(h string) ([]byte, error) {
 	}
 	return hex.DecodeString(h)
 }
+
+// ExpandProof returns the siblings of the Merkle Proof in the GenerateProof format for
+// each level, from the root, including the empty ones, so there are numLevels-1 siblings
+func ExpandProof(proof []byte, numLevels int) ([]Hash, error) {
+	var empties [32]byte
+	hashLen := len(EmptyNodeValue)
+	if numLevels < minNumLevels || numLevels > maxNumLevels {
+		return nil, ErrInvalidNumLevels
+	}
+	if len(proof) < len(empties) || (len(proof)-len(empties))%hashLen != 0 {
+		return nil, ErrInvalidProof
+	}
+	copy(empties[:], proof[:len(empties)])
+	// there can not be siblings out of the levels of the tree
+	for level := numLevels - 1; level < len(empties)*8; level++ {
+		if testbitmap(empties[:], uint(level)) {
+			return nil, ErrInvalidProof
+		}
+	}
+	siblings := make([]Hash, numLevels-1)
+	pos := len(empties)
+	// the siblings of the proof go from the leaf to the root
+	for level := numLevels - 2; level >= 0; level-- {
+		if testbitmap(empties[:], uint(level)) {
+			if pos >= len(proof) {
+				return nil, ErrInvalidProof
+			}
+			copy(siblings[level][:], proof[pos:pos+hashLen])
+			pos += hashLen
+		}
+	}
+	if pos != len(proof) {
+		return nil, ErrInvalidProof
+	}
+	return siblings, nil
+}
+
+// CompressProof returns the Merkle Proof in the GenerateProof format of the siblings of
+// each level, from the root, as returned by ExpandProof, omitting the empty ones
+func CompressProof(siblings []Hash) ([]byte, error) {
+	if len(siblings) < minNumLevels-1 || len(siblings) > maxNumLevels-1 {
+		return nil, ErrInvalidNumLevels
+	}
+	return compressProof(siblings), nil
+}
+
+// compressProof returns the Merkle Proof of the siblings of each level, from the root,
+// omitting the empty ones
+func compressProof(siblings []Hash) []byte {
+	var empties [32]byte
+	var siblingsBytes []byte
+	for level := len(siblings) - 1; level >= 0; level-- {
+		if !bytes.Equal(siblings[level][:], EmptyNodeValue[:]) {
+			setbitmap(empties[:], uint(level))
+			siblingsBytes = append(siblingsBytes, siblings[level][:]...)
+		}
+	}
+	return append(empties[:], siblingsBytes...)
+}
@@ -1,6 +1,7 @@
 package merkletree
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,3 +40,56 @@ func TestBytesToUint32(t *testing.T) {
 	u := BytesToUint32([]byte{0xe7, 0x3, 0x0, 0x0})
 	assert.Equal(t, uint32(999), u)
 }
+
+func TestExpandCompressProof(t *testing.T) {
+	for _, numLevels := range []int{140, 8} {
+		mt := newTestingMerkle(t, numLevels)
+		defer mt.storage.Close()
+		leafs := addTestLeafs(t, mt, 30)
+
+		his := []Hash{HashBytes([]byte("not in the tree"))}
+		for _, leaf := range leafs {
+			his = append(his, HashBytes(leaf.Index()))
+		}
+		for _, hi := range his {
+			proof, err := mt.GenerateProof(hi)
+			assert.Nil(t, err)
+			siblings, err := ExpandProof(proof, numLevels)
+			assert.Nil(t, err)
+			assert.Equal(t, numLevels-1, len(siblings))
+			compressed, err := CompressProof(siblings)
+			assert.Nil(t, err)
+			assert.Equal(t, proof, compressed)
+			expanded, err := ExpandProof(compressed, numLevels)
+			assert.Nil(t, err)
+			assert.Equal(t, siblings, expanded)
+
+			value, err := mt.GetValueInPos(hi)
+			assert.Nil(t, err)
+			ht := EmptyNodeValue
+			if !bytes.Equal(value, EmptyNodeValue[:]) {
+				ht = HashBytes(value)
+			}
+			assert.True(t, CheckProof(mt.Root(), compressed, hi, ht, numLevels))
+		}
+	}
+
+	// the full array of siblings of a tree of 3 levels
+	siblings := []Hash{EmptyNodeValue, HashBytes([]byte("sibling"))}
+	proof, err := CompressProof(siblings)
+	assert.Nil(t, err)
+	assert.Equal(t, 64, len(proof))
+	assert.True(t, testbitmap(proof[:32], 1))
+	expanded, err := ExpandProof(proof, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, siblings, expanded)
+
+	_, err = CompressProof(nil)
+	assert.Equal(t, ErrInvalidNumLevels, err)
+	_, err = CompressProof(make([]Hash, 257))
+	assert.Equal(t, ErrInvalidNumLevels, err)
+	_, err = ExpandProof(proof, 1)
+	assert.Equal(t, ErrInvalidNumLevels, err)
+	_, err = ExpandProof(proof, 2)
+	assert.Equal(t, ErrInvalidProof, err)
+}
@@ -0,0 +1,107 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+)
+
+var (
+	// ErrInvalidProof is an error that indicates that a proof can not be parsed
+	ErrInvalidProof = errors.New("invalid proof")
+	// ErrValueConflict is an error that indicates that the position of a value in the merkletree is used by another value
+	ErrValueConflict = errors.New("position used by another value")
+)
+
+// ValueProof is a Merkle Proof of a Value, that contains the leaf data needed to verify it
+type ValueProof struct {
+	Existence   bool   // if the value is in the tree, else the proof is of the empty position of the value
+	IndexLength uint32 // length of the index of the value
+	Value       []byte // bytes of the value
+	Proof       []byte // Merkle Proof in the GenerateProof format
+}
+
+// GenerateValueProof generates the ValueProof of the Value v for the current root.
+// When v is not in the tree, returns the proof of its empty position
+func (mt *MerkleTree) GenerateValueProof(v Value) (*ValueProof, error) {
+	if err := validateValue(mt.hasher, v); err != nil {
+		return nil, err
+	}
+	hi := mt.hasher.HashBytes(v.Bytes()[:v.IndexLength()])
+	valueInPos, err := mt.GetValueInPos(hi)
+	if err != nil {
+		return nil, err
+	}
+	existence := bytes.Equal(valueInPos, v.Bytes())
+	if !existence && !bytes.Equal(valueInPos, EmptyNodeValue[:]) {
+		return nil, ErrValueConflict
+	}
+	proof, err := mt.GenerateProof(hi)
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, len(v.Bytes()))
+	copy(value, v.Bytes())
+	return &ValueProof{
+		Existence:   existence,
+		IndexLength: v.IndexLength(),
+		Value:       value,
+		Proof:       proof,
+	}, nil
+}
+
+// CheckValueProof validates the ValueProof for the root, computing the hash of the
+// index (Hi) and the hash of the value (Ht) from the value contained in the proof
+func CheckValueProof(root Hash, p *ValueProof, numLevels int) bool {
+	return CheckValueProofWithHasher(Keccak256Hasher, root, p, numLevels)
+}
+
+// CheckValueProofWithHasher validates the ValueProof for the root of a tree with the Hasher
+func CheckValueProofWithHasher(h Hasher, root Hash, p *ValueProof, numLevels int) bool {
+	if numLevels < minNumLevels || numLevels > maxNumLevels {
+		return false
+	}
+	if int(p.IndexLength) > len(p.Value) {
+		return false
+	}
+	hi := h.HashBytes(p.Value[:p.IndexLength])
+	ht := EmptyNodeValue
+	if p.Existence {
+		ht = h.HashBytes(p.Value)
+	}
+	return CheckProofWithHasher(h, root, p.Proof, hi, ht, numLevels)
+}
+
+// Bytes returns the ValueProof encoded in a byte array
+func (p *ValueProof) Bytes() []byte {
+	var b []byte
+	if p.Existence {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = append(b, Uint32ToBytes(p.IndexLength)...)
+	b = append(b, Uint32ToBytes(uint32(len(p.Value)))...)
+	b = append(b, p.Value...)
+	b = append(b, p.Proof...)
+	return b
+}
+
+// ParseValueProof returns the ValueProof encoded in the byte array
+func ParseValueProof(b []byte) (*ValueProof, error) {
+	if len(b) < 9 || b[0] > 1 {
+		return nil, ErrInvalidProof
+	}
+	valueLength := BytesToUint32(b[5:9])
+	if uint64(len(b)-9) < uint64(valueLength) {
+		return nil, ErrInvalidProof
+	}
+	p := &ValueProof{
+		Existence:   b[0] == 1,
+		IndexLength: BytesToUint32(b[1:5]),
+		Value:       make([]byte, valueLength),
+		Proof:       make([]byte, len(b)-9-int(valueLength)),
+	}
+	copy(p.Value, b[9:])
+	copy(p.Proof, b[9+int(valueLength):])
+	return p, nil
+}
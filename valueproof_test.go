@@ -0,0 +1,63 @@
+package merkletree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueProof(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	leaf := newTestBytesLeaf("this is a test leaf", 15)
+	leaf2 := newTestBytesLeaf("this is a second test leaf", 15)
+	assert.Nil(t, mt.Add(leaf))
+	assert.Nil(t, mt.Add(leaf2))
+
+	p, err := mt.GenerateValueProof(leaf2)
+	assert.Nil(t, err)
+	assert.True(t, p.Existence)
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001fd8e1a60cdb23c0c7b2cf8462c99fafd905054dccb0ed75e7c8a7d6806749b6b", BytesToHex(p.Proof))
+	assert.True(t, CheckValueProof(mt.Root(), p, mt.NumLevels()))
+
+	parsed, err := ParseValueProof(p.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, p, parsed)
+	assert.True(t, CheckValueProof(mt.Root(), parsed, mt.NumLevels()))
+
+	// a modified value is not valid
+	parsed.Value[len(parsed.Value)-1]++
+	assert.False(t, CheckValueProof(mt.Root(), parsed, mt.NumLevels()))
+
+	// proof of a value that is not in the tree
+	leaf3 := newTestBytesLeaf("this is a third test leaf", 15)
+	p, err = mt.GenerateValueProof(leaf3)
+	assert.Nil(t, err)
+	assert.False(t, p.Existence)
+	assert.True(t, CheckValueProof(mt.Root(), p, mt.NumLevels()))
+	p.Existence = true
+	assert.False(t, CheckValueProof(mt.Root(), p, mt.NumLevels()))
+
+	// the position of the value has another value with the same index
+	_, err = mt.GenerateValueProof(newTestBytesLeaf("this is a test leaf, modified", 15))
+	assert.Equal(t, ErrValueConflict, err)
+}
+
+func TestParseValueProofErrors(t *testing.T) {
+	_, err := ParseValueProof([]byte{})
+	assert.Equal(t, ErrInvalidProof, err)
+	_, err = ParseValueProof([]byte{2, 0, 0, 0, 0, 0, 0, 0, 0})
+	assert.Equal(t, ErrInvalidProof, err)
+	_, err = ParseValueProof([]byte{1, 0, 0, 0, 0, 5, 0, 0, 0, 1})
+	assert.Equal(t, ErrInvalidProof, err)
+
+	// malformed proofs are not valid
+	p := &ValueProof{IndexLength: 1, Value: []byte{1}, Proof: []byte{1, 2, 3}}
+	assert.False(t, CheckValueProof(EmptyNodeValue, p, 140))
+	p.Proof = make([]byte, 32)
+	p.Proof[31] = 1
+	assert.False(t, CheckValueProof(EmptyNodeValue, p, 140))
+	p.IndexLength = 2
+	assert.False(t, CheckValueProof(EmptyNodeValue, p, 140))
+}
@@ -0,0 +1,150 @@
+// Package verifier checks the Merkle Proofs of the go-merkletree trees without a database,
+// using only the standard library, for the light clients that do not store the tree
+package verifier
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+)
+
+const (
+	// minNumLevels is the minimum number of levels of a tree, the root and the leafs
+	minNumLevels = 2
+	// maxNumLevels is the maximum number of levels of a tree, where the path uses all the bits of the Hash
+	maxNumLevels = 8*len(EmptyNodeValue) + 1
+)
+
+var (
+	// ErrInvalidProof is an error that indicates that a proof can not be parsed
+	ErrInvalidProof = errors.New("invalid proof")
+	// ErrInvalidIndexLength is an error that indicates that the index length of a leaf is 0 or greater than its length
+	ErrInvalidIndexLength = errors.New("invalid index length")
+	// EmptyNodeValue is the hash of an empty node, all to zero
+	EmptyNodeValue = Hash{}
+)
+
+// Hash used in the tree, is the [32]byte keccak()
+type Hash [32]byte
+
+// Hex returns a hex string from the Hash type
+func (hash Hash) Hex() string {
+	return "0x" + hex.EncodeToString(hash[:])
+}
+
+// HashBytes performs a Keccak256 hash over the bytes
+func HashBytes(b []byte) Hash {
+	return Hash(keccak256(b))
+}
+
+// LeafHashes returns the Hash of the index (Hi) and the Hash of the whole value (Ht) of
+// a leaf, that are used to check its proof
+func LeafHashes(value []byte, indexLength uint32) (hi Hash, ht Hash, err error) {
+	if indexLength == 0 || uint64(indexLength) > uint64(len(value)) {
+		return hi, ht, ErrInvalidIndexLength
+	}
+	return HashBytes(value[:indexLength]), HashBytes(value), nil
+}
+
+func testbitmap(bitmap []byte, bitno uint) bool {
+	return bitmap[uint(len(bitmap))-bitno/8-1]&(1<<(bitno%8)) > 0
+}
+
+// pathBit returns the branch of the path of the Hi at the level, being 0 the root
+func pathBit(hi Hash, level int) bool {
+	return testbitmap(hi[:], uint(level))
+}
+
+// parent returns the hash of the node with the childs, that is empty if both are empty
+func parent(childL, childR Hash) Hash {
+	if bytes.Equal(childL[:], EmptyNodeValue[:]) && bytes.Equal(childR[:], EmptyNodeValue[:]) {
+		return EmptyNodeValue
+	}
+	b := make([]byte, 0, 2*len(EmptyNodeValue))
+	b = append(b, childL[:]...)
+	b = append(b, childR[:]...)
+	return HashBytes(b)
+}
+
+// ParseProof returns the siblings of the Merkle Proof for each level, from the root,
+// including the empty ones. The proof is a bitmap of the non empty siblings followed
+// by them, from the leaf to the root
+func ParseProof(proof []byte, numLevels int) ([]Hash, error) {
+	var empties [32]byte
+	hashLen := len(EmptyNodeValue)
+	if numLevels < minNumLevels || numLevels > maxNumLevels {
+		return nil, ErrInvalidProof
+	}
+	if len(proof) < len(empties) || (len(proof)-len(empties))%hashLen != 0 {
+		return nil, ErrInvalidProof
+	}
+	copy(empties[:], proof[:len(empties)])
+	// there can not be siblings out of the levels of the tree
+	for level := numLevels - 1; level < len(empties)*8; level++ {
+		if testbitmap(empties[:], uint(level)) {
+			return nil, ErrInvalidProof
+		}
+	}
+	siblings := make([]Hash, numLevels-1)
+	pos := len(empties)
+	for level := numLevels - 2; level >= 0; level-- {
+		if testbitmap(empties[:], uint(level)) {
+			if pos >= len(proof) {
+				return nil, ErrInvalidProof
+			}
+			copy(siblings[level][:], proof[pos:pos+hashLen])
+			pos += hashLen
+		}
+	}
+	if pos != len(proof) {
+		return nil, ErrInvalidProof
+	}
+	return siblings, nil
+}
+
+// RootFromSiblings returns the root of the tree with the leaf of the Hi and Ht, and the
+// siblings of each level returned by ParseProof
+func RootFromSiblings(siblings []Hash, hi Hash, ht Hash) Hash {
+	nodeHash := ht
+	for level := len(siblings) - 1; level >= 0; level-- {
+		if pathBit(hi, level) {
+			nodeHash = parent(siblings[level], nodeHash)
+		} else {
+			nodeHash = parent(nodeHash, siblings[level])
+		}
+	}
+	return nodeHash
+}
+
+// CheckProof validates the Merkle Proof for the leaf of the Hi and Ht and the root, as the
+// CheckProof of the merkletree package. For the proof of an empty position, Ht is the EmptyNodeValue
+func CheckProof(root Hash, proof []byte, hi Hash, ht Hash, numLevels int) bool {
+	var empties [32]byte
+	hashLen := len(EmptyNodeValue)
+	if numLevels < minNumLevels || numLevels > maxNumLevels {
+		return false
+	}
+	if len(proof) < len(empties) || (len(proof)-len(empties))%hashLen != 0 {
+		return false
+	}
+	copy(empties[:], proof[:len(empties)])
+
+	nodeHash := ht
+	pos := len(empties)
+	for level := numLevels - 2; level >= 0; level-- {
+		sibling := EmptyNodeValue
+		if testbitmap(empties[:], uint(level)) {
+			if pos >= len(proof) {
+				return false
+			}
+			copy(sibling[:], proof[pos:pos+hashLen])
+			pos += hashLen
+		}
+		if pathBit(hi, level) {
+			nodeHash = parent(sibling, nodeHash)
+		} else {
+			nodeHash = parent(nodeHash, sibling)
+		}
+	}
+	return bytes.Equal(nodeHash[:], root[:])
+}
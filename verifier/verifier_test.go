@@ -0,0 +1,97 @@
+package verifier
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/arnaucube/go-merkletree"
+	"github.com/dchest/uniuri"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func newTestingDB(t *testing.T) *leveldb.DB {
+	db, err := leveldb.OpenFile("../tmp/db"+uniuri.New()+"-"+fmt.Sprint(time.Now().Unix()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestHashBytes(t *testing.T) {
+	assert.Equal(t, "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", HashBytes(nil).Hex())
+	assert.Equal(t, "0x9c22ff5f21f0b81b113e63f7db6da94fedef11b2119b4088b89664fb9a3cb658", HashBytes([]byte("test")).Hex())
+	// the lengths around the size of the blocks
+	b := make([]byte, 300)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	for i := 0; i <= len(b); i++ {
+		assert.Equal(t, Hash(merkletree.HashBytes(b[:i])), HashBytes(b[:i]))
+	}
+}
+
+func TestCheckProof(t *testing.T) {
+	for _, numLevels := range []int{140, 8} {
+		db := newTestingDB(t)
+		defer db.Close()
+		mt, err := merkletree.New(db, numLevels)
+		assert.Nil(t, err)
+
+		var leafs []merkletree.Leaf
+		for i := 0; i < 30; i++ {
+			leaf := merkletree.NewLeaf([]byte(strconv.Itoa(i)+" index"), []byte("data"))
+			if mt.Add(leaf) == nil {
+				leafs = append(leafs, leaf)
+			}
+		}
+		root := Hash(mt.Root())
+		for _, leaf := range leafs {
+			proof, err := mt.GenerateProof(merkletree.HashBytes(leaf.Index()))
+			assert.Nil(t, err)
+			hi, ht, err := LeafHashes(leaf.Bytes(), leaf.IndexLength())
+			assert.Nil(t, err)
+			assert.True(t, CheckProof(root, proof, hi, ht, numLevels))
+			assert.False(t, CheckProof(root, proof, hi, HashBytes([]byte("other value")), numLevels))
+
+			siblings, err := ParseProof(proof, numLevels)
+			assert.Nil(t, err)
+			assert.Equal(t, root, RootFromSiblings(siblings, hi, ht))
+		}
+
+		// proof of an empty position
+		hi := HashBytes([]byte("not in the tree"))
+		proof, err := mt.GenerateProof(merkletree.Hash(hi))
+		assert.Nil(t, err)
+		value, err := mt.GetValueInPos(merkletree.Hash(hi))
+		assert.Nil(t, err)
+		expected := merkletree.CheckProof(mt.Root(), proof, merkletree.Hash(hi), merkletree.EmptyNodeValue, numLevels)
+		assert.Equal(t, expected, CheckProof(root, proof, hi, EmptyNodeValue, numLevels))
+		assert.Equal(t, merkletree.EmptyNodeValue[:], value)
+		assert.True(t, CheckProof(root, proof, hi, EmptyNodeValue, numLevels))
+	}
+}
+
+func TestCheckProofInvalid(t *testing.T) {
+	hi := HashBytes([]byte("index"))
+	assert.False(t, CheckProof(EmptyNodeValue, []byte{1, 2, 3}, hi, EmptyNodeValue, 140))
+	assert.False(t, CheckProof(EmptyNodeValue, make([]byte, 32), hi, EmptyNodeValue, 1))
+	assert.True(t, CheckProof(EmptyNodeValue, make([]byte, 32), hi, EmptyNodeValue, 140))
+
+	// a sibling in the bitmap that is not in the proof
+	proof := make([]byte, 32)
+	proof[31] = 1
+	assert.False(t, CheckProof(EmptyNodeValue, proof, hi, EmptyNodeValue, 140))
+	_, err := ParseProof(proof, 140)
+	assert.Equal(t, ErrInvalidProof, err)
+	// a sibling out of the levels of the tree
+	proof[0] = 1
+	proof[31] = 0
+	_, err = ParseProof(append(proof, make([]byte, 32)...), 140)
+	assert.Equal(t, ErrInvalidProof, err)
+
+	_, _, err = LeafHashes([]byte("value"), 6)
+	assert.Equal(t, ErrInvalidIndexLength, err)
+}
@@ -0,0 +1,119 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// Inconsistency describes a stored node that does not match its content or its position in the tree
+type Inconsistency struct {
+	Hash   Hash   // key of the node in the database
+	Level  int    // depth of the node, being 0 the root
+	Reason string // description of the inconsistency
+}
+
+func (inc Inconsistency) String() string {
+	return fmt.Sprintf("level %d - '%s': %s", inc.Level, inc.Hash.Hex(), inc.Reason)
+}
+
+// verifyLeaf checks the leaf data of a final or value node, returning its path
+func (mt *MerkleTree) verifyLeaf(indexLength uint32, nodeBytes []byte, route []bool) ([]bool, string) {
+	if indexLength == 0 || int(indexLength) > len(nodeBytes) {
+		return nil, fmt.Sprintf("invalid index length %d for a value of %d bytes", indexLength, len(nodeBytes))
+	}
+	path := getPath(mt.numLevels, mt.hasher.HashBytes(nodeBytes[:indexLength]))
+	// the branch taken from the root to the node has to be the beginning of the leaf path
+	for i := range route {
+		if path[mt.numLevels-2-i] != route[i] {
+			return nil, fmt.Sprintf("leaf path differs from its position at level %d", i)
+		}
+	}
+	return path, ""
+}
+
+// checkNode checks that the content of the node matches its hash, and that it can
+// be in the position of the route from the root, returning the reason when it is not
+func (mt *MerkleTree) checkNode(nodeHash Hash, route []bool, nodeType byte, indexLength uint32, nodeBytes []byte) string {
+	level := len(route)
+	switch nodeType {
+	case normalNodeType:
+		if level >= mt.numLevels-1 {
+			return "normal node at the bottom level"
+		}
+		if indexLength != 0 {
+			return fmt.Sprintf("normal node with index length %d", indexLength)
+		}
+		if len(nodeBytes) != 2*len(EmptyNodeValue) {
+			return fmt.Sprintf("normal node of %d bytes", len(nodeBytes))
+		}
+		node := parseNodeBytes(nodeBytes)
+		if h := node.Ht(mt.hasher); !bytes.Equal(h[:], nodeHash[:]) {
+			return fmt.Sprintf("normal node hash is %s", h.Hex())
+		}
+		if bytes.Equal(node.ChildL[:], EmptyNodeValue[:]) && bytes.Equal(node.ChildR[:], EmptyNodeValue[:]) {
+			return "normal node with both childs empty"
+		}
+	case finalNodeType:
+		path, reason := mt.verifyLeaf(indexLength, nodeBytes, route)
+		if reason != "" {
+			return "final node " + reason
+		}
+		h := calcHashFromLeafAndLevel(mt.hasher, mt.numLevels-1-level, path, mt.hasher.HashBytes(nodeBytes))
+		if !bytes.Equal(h[:], nodeHash[:]) {
+			return fmt.Sprintf("final node hash at its level is %s", h.Hex())
+		}
+	case valueNodeType:
+		if level != mt.numLevels-1 {
+			return "value node out of the bottom level"
+		}
+		if _, reason := mt.verifyLeaf(indexLength, nodeBytes, route); reason != "" {
+			return "value node " + reason
+		}
+		if h := mt.hasher.HashBytes(nodeBytes); !bytes.Equal(h[:], nodeHash[:]) {
+			return fmt.Sprintf("value node hash is %s", h.Hex())
+		}
+	default:
+		return fmt.Sprintf("invalid node type %d", nodeType)
+	}
+	return ""
+}
+
+func (mt *MerkleTree) verifyLevel(ctx context.Context, nodeHash Hash, route []bool, incs []Inconsistency) ([]Inconsistency, error) {
+	if bytes.Equal(nodeHash[:], EmptyNodeValue[:]) {
+		return incs, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	level := len(route)
+	nodeType, indexLength, nodeBytes, err := mt.Get(nodeHash)
+	if err != nil {
+		return append(incs, Inconsistency{nodeHash, level, fmt.Sprintf("can not get the node: %s", err)}), nil
+	}
+	if reason := mt.checkNode(nodeHash, route, nodeType, indexLength, nodeBytes); reason != "" {
+		return append(incs, Inconsistency{nodeHash, level, reason}), nil
+	}
+	if nodeType == byte(normalNodeType) {
+		node := parseNodeBytes(nodeBytes)
+		if incs, err = mt.verifyLevel(ctx, node.ChildL, append(route[:level:level], false), incs); err != nil {
+			return nil, err
+		}
+		return mt.verifyLevel(ctx, node.ChildR, append(route[:level:level], true), incs)
+	}
+	return incs, nil
+}
+
+// Verify walks the tree from the given root, recomputing the hash of every node and
+// checking that each leaf is in the position that its index determines. Returns all
+// the inconsistencies found, being empty when the tree is correct
+func (mt *MerkleTree) Verify(root Hash) []Inconsistency {
+	incs, _ := mt.VerifyContext(context.Background(), root)
+	return incs
+}
+
+// VerifyContext walks the tree from the given root as Verify, returning the error of
+// the context if it is done before all the nodes have been checked
+func (mt *MerkleTree) VerifyContext(ctx context.Context, root Hash) ([]Inconsistency, error) {
+	return mt.verifyLevel(ctx, root, []bool{}, nil)
+}
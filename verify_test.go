@@ -0,0 +1,59 @@
+package merkletree
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	assert.Equal(t, 0, len(mt.Verify(mt.Root())))
+	for i := 0; i < 50; i++ {
+		assert.Nil(t, mt.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15)))
+	}
+	assert.Equal(t, 0, len(mt.Verify(mt.Root())))
+
+	mt4 := newTestingMerkle(t, 4)
+	defer mt4.storage.Close()
+	for i := 0; i < 20; i++ {
+		mt4.Add(newTestBytesLeaf(strconv.Itoa(i)+" this is a test leaf", 15))
+	}
+	assert.Equal(t, 0, len(mt4.Verify(mt4.Root())))
+}
+
+func TestVerifyInconsistencies(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	leaf1 := newTestBytesLeaf("this is a test leaf", 15)
+	leaf2 := newTestBytesLeaf("this is a second test leaf", 15)
+	assert.Nil(t, mt.Add(leaf1))
+	assert.Nil(t, mt.Add(leaf2))
+
+	_, _, rootBytes, err := mt.Get(mt.Root())
+	assert.Nil(t, err)
+	root := parseNodeBytes(rootBytes)
+
+	// a missing node and a final node with a value of another leaf
+	assert.Nil(t, mt.storage.Delete(root.ChildL[:], nil))
+	assert.Nil(t, mt.Insert(root.ChildR, finalNodeType, 15, []byte("this is a third test leaf")))
+
+	incs := mt.Verify(mt.Root())
+	assert.Equal(t, 2, len(incs))
+	assert.Equal(t, root.ChildL, incs[0].Hash)
+	assert.Equal(t, 1, incs[0].Level)
+	assert.Contains(t, incs[0].Reason, "can not get the node")
+	assert.Equal(t, root.ChildR, incs[1].Hash)
+	assert.Contains(t, incs[1].Reason, "final node")
+
+	// a root node that does not match its hash
+	assert.Nil(t, mt.Insert(mt.Root(), normalNodeType, 0, append(root.ChildR[:], root.ChildL[:]...)))
+	incs = mt.Verify(mt.Root())
+	assert.Equal(t, 1, len(incs))
+	assert.Equal(t, 0, incs[0].Level)
+	assert.Contains(t, incs[0].Reason, "normal node hash is")
+}
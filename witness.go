@@ -0,0 +1,70 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Witness is the input of a circuit of fixed depth that verifies the Merkle Proof of a
+// leaf, with the hashes in hex. The siblings and the path bits go from the root, so the
+// element i of both is the one of the level i, and there are numLevels-1 of each
+type Witness struct {
+	Root      string   `json:"root"`
+	Siblings  []string `json:"siblings"`  // siblings of each level, the empty ones included
+	Path      []int    `json:"path"`      // branch of the path at each level, 1 for the right child
+	LeafHash  string   `json:"leafHash"`  // hash of the leaf, the EmptyNodeValue for the proofs of non existence
+	Existence int      `json:"existence"` // 1 if the leaf is in the tree, 0 if its position is empty
+}
+
+// GenerateWitness returns the Witness of the Merkle Proof of the Value for the root,
+// that is of its existence or of the emptiness of its position. Returns ErrInvalidProof
+// if the proof can not be checked with CheckProof
+func GenerateWitness(root Hash, proof []byte, v Value, existence bool, numLevels int) (*Witness, error) {
+	return GenerateWitnessWithHasher(Keccak256Hasher, root, proof, v, existence, numLevels)
+}
+
+// GenerateWitnessWithHasher returns the Witness of the Merkle Proof of the Value for the
+// root of a tree with the Hasher
+func GenerateWitnessWithHasher(h Hasher, root Hash, proof []byte, v Value, existence bool, numLevels int) (*Witness, error) {
+	if err := validateValue(h, v); err != nil {
+		return nil, err
+	}
+	siblings, err := ExpandProof(proof, numLevels)
+	if err != nil {
+		return nil, err
+	}
+	hi := h.HashBytes(v.Bytes()[:v.IndexLength()])
+	leafHash := EmptyNodeValue
+	if existence {
+		leafHash = h.HashBytes(v.Bytes())
+	}
+	if !CheckProofWithHasher(h, root, proof, hi, leafHash, numLevels) {
+		return nil, ErrInvalidProof
+	}
+
+	w := &Witness{
+		Root:     root.Hex(),
+		Siblings: make([]string, len(siblings)),
+		Path:     make([]int, len(siblings)),
+		LeafHash: leafHash.Hex(),
+	}
+	// getPath goes from the leaf to the root
+	path := getPath(numLevels, hi)
+	for level := range siblings {
+		w.Siblings[level] = siblings[level].Hex()
+		if path[numLevels-2-level] {
+			w.Path[level] = 1
+		}
+	}
+	if existence {
+		w.Existence = 1
+	}
+	return w, nil
+}
+
+// WriteJSON writes the Witness in the JSON format of the circuit inputs
+func (w *Witness) WriteJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(w)
+}
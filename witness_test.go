@@ -0,0 +1,92 @@
+package merkletree
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// witnessRoot returns the root computed from the fields of the Witness, as the circuit does
+func witnessRoot(t *testing.T, w *Witness) string {
+	nodeHash, err := HexToBytes(w.LeafHash)
+	assert.Nil(t, err)
+	for level := len(w.Siblings) - 1; level >= 0; level-- {
+		sibling, err := HexToBytes(w.Siblings[level])
+		assert.Nil(t, err)
+		if bytes.Equal(nodeHash, EmptyNodeValue[:]) && bytes.Equal(sibling, EmptyNodeValue[:]) {
+			continue
+		}
+		var node []byte
+		if w.Path[level] == 1 {
+			node = append(sibling, nodeHash...)
+		} else {
+			node = append(nodeHash, sibling...)
+		}
+		h := HashBytes(node)
+		nodeHash = h[:]
+	}
+	return BytesToHex(nodeHash)
+}
+
+// checkWitnessFixture compares the JSON of the Witness with the one of the file
+func checkWitnessFixture(t *testing.T, w *Witness, file string) {
+	var b bytes.Buffer
+	assert.Nil(t, w.WriteJSON(&b))
+	expected, err := ioutil.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), b.String())
+}
+
+func TestWitness4(t *testing.T) {
+	mt := newTestingMerkle(t, 4)
+	defer mt.storage.Close()
+
+	zeros := make([]byte, 32)
+	zeros[31] = 1
+	assert.Nil(t, mt.Add(vt{zeros, 1}))
+	v := vt{zeros, 2}
+	assert.Nil(t, mt.Add(v))
+	proof, err := mt.GenerateProof(HashBytes(v.Bytes()[:v.IndexLength()]))
+	assert.Nil(t, err)
+	w, err := GenerateWitness(mt.Root(), proof, v, true, mt.NumLevels())
+	assert.Nil(t, err)
+	assert.Equal(t, "0xc1b95ffbb999a6dd7a472a610a98891ffae95cc973d1d1e21acfdd68db830b51", w.Root)
+	assert.Equal(t, 3, len(w.Siblings))
+	assert.Equal(t, w.Root, witnessRoot(t, w))
+	checkWitnessFixture(t, w, "testdata/witness4.json")
+
+	// the proof of the position of a value that is not in the tree
+	absent := vt{[]byte("this is not in the tree"), 15}
+	proof, err = mt.GenerateProof(HashBytes(absent.Bytes()[:absent.IndexLength()]))
+	assert.Nil(t, err)
+	w, err = GenerateWitness(mt.Root(), proof, absent, false, mt.NumLevels())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, w.Existence)
+	assert.Equal(t, EmptyNodeValue.Hex(), w.LeafHash)
+	assert.Equal(t, w.Root, witnessRoot(t, w))
+	_, err = GenerateWitness(mt.Root(), proof, absent, true, mt.NumLevels())
+	assert.Equal(t, ErrInvalidProof, err)
+}
+
+func TestWitness140(t *testing.T) {
+	mt := newTestingMerkle(t, 140)
+	defer mt.storage.Close()
+
+	zeros := make([]byte, 32)
+	zeros[31] = 1
+	var v vt
+	for i := 1; i < len(zeros)-1; i++ {
+		v = vt{zeros, uint32(i)}
+		assert.Nil(t, mt.Add(v))
+	}
+	proof, err := mt.GenerateProof(HashBytes(v.Bytes()[:v.IndexLength()]))
+	assert.Nil(t, err)
+	w, err := GenerateWitness(mt.Root(), proof, v, true, mt.NumLevels())
+	assert.Nil(t, err)
+	assert.Equal(t, "0x35f83288adf03bfb61d8d57fab9ed092da79833b58bbdbe9579b636753494ebd", w.Root)
+	assert.Equal(t, 139, len(w.Siblings))
+	assert.Equal(t, w.Root, witnessRoot(t, w))
+	checkWitnessFixture(t, w, "testdata/witness140.json")
+}